@@ -0,0 +1,182 @@
+package liguetaxi
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Endpoints of the mutating operations recorded by Audit.
+var auditedEndpoints = map[endpoint]bool{
+	createUserEndpoint:       true,
+	updateUserEndpoint:       true,
+	updateUserStatusEndpoint: true,
+	createClassifierEndpoint: true,
+	updateClassifierEndpoint: true,
+	deleteUserEndpoint:       true,
+	deleteClassifierEndpoint: true,
+}
+
+// Payload fields replaced by redactedValue in the audit records.
+var redactedFields = map[string]bool{
+	"user_password": true,
+}
+
+const redactedValue = "[REDACTED]"
+
+// actorKey is the context key for the actor.
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor, i.e. who is
+// performing the operations made with it, for the audit records.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx, if any.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	a, ok := ctx.Value(actorKey{}).(string)
+	return a, ok && a != ""
+}
+
+// AuditRecord is the record of a mutating operation.
+type AuditRecord struct {
+	Time     time.Time              `json:"time"`
+	Actor    string                 `json:"actor,omitempty"`
+	Endpoint string                 `json:"endpoint"`
+	Payload  map[string]interface{} `json:"payload,omitempty"`
+	Status   ReqStatus              `json:"status"`
+	Message  string                 `json:"message,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// AuditSink receives the audit records.
+// Implementations must be safe for concurrent use.
+type AuditSink interface {
+	Record(ctx context.Context, r AuditRecord) error
+}
+
+// Audit returns a Middleware that sends to the sink a record of every
+// user and classifier field creation and update. Passwords are redacted
+// from the payload. If not nil, onError is called with the records the
+// sink failed to receive.
+func Audit(sink AuditSink, onError func(r AuditRecord, err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, method, e string, body, output interface{}) error {
+			if !auditedEndpoints[endpoint(e)] {
+				return next(ctx, method, e, body, output)
+			}
+
+			err := next(ctx, method, e, body, output)
+
+			r := AuditRecord{
+				Time:     time.Now(),
+				Endpoint: e,
+				Payload:  redact(body),
+			}
+			r.Actor, _ = ActorFromContext(ctx)
+
+			switch o := output.(type) {
+			case *OperationResponse:
+				r.Status, r.Message = o.Status, o.Message.String()
+			case *ClassifierOperationResponse:
+				r.Status, r.Message = o.Status, o.Message.String()
+			}
+
+			if err != nil {
+				r.Error = err.Error()
+			}
+
+			if serr := sink.Record(ctx, r); serr != nil && onError != nil {
+				onError(r, serr)
+			}
+
+			return err
+		}
+	}
+}
+
+// redact returns the body as a map, without the redacted fields.
+func redact(body interface{}) map[string]interface{} {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+
+	for k := range m {
+		if redactedFields[k] {
+			m[k] = redactedValue
+		}
+	}
+	return m
+}
+
+// MemoryAuditSink is an AuditSink keeping the records in memory.
+type MemoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+// Record implements the AuditSink interface.
+func (s *MemoryAuditSink) Record(ctx context.Context, r AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, r)
+	return nil
+}
+
+// Records returns a copy of the records received.
+func (s *MemoryAuditSink) Records() []AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]AuditRecord(nil), s.records...)
+}
+
+// FileAuditSink is an AuditSink appending the records
+// to a file as JSON lines.
+type FileAuditSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileAuditSink returns a FileAuditSink appending to the file
+// at path, which is created if it does not exist.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileAuditSink{f: f}, nil
+}
+
+// Record implements the AuditSink interface.
+func (s *FileAuditSink) Record(ctx context.Context, r AuditRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.f.Close()
+}
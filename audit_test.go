@@ -0,0 +1,201 @@
+package liguetaxi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestActorFromContext(t *testing.T) {
+	if _, ok := ActorFromContext(context.Background()); ok {
+		t.Error("got actor from empty context; want none.")
+	}
+
+	if a, ok := ActorFromContext(WithActor(context.Background(), "admin")); !ok || a != "admin" {
+		t.Errorf("got ActorFromContext(): %s, %t; want admin, true.", a, ok)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	sink := &MemoryAuditSink{}
+	reqErr := errors.New("Error")
+
+	c := NewClient(&url.URL{}, "", nil)
+	c.common.client = requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		switch o := output.(type) {
+		case *OperationResponse:
+			*o = OperationResponse{Status: ReqStatusOK, Message: "OK"}
+		case *ClassifierOperationResponse:
+			return reqErr
+		}
+		return nil
+	})
+	c.Use(Audit(sink, nil))
+	us := c.User
+
+	ctx := WithActor(context.Background(), "admin")
+
+	us.Read(ctx, "123", "")
+	us.Create(ctx, &User{ID: "123", Name: "Test", Password: "secret"})
+	us.UpdateStatus(ctx, &UserStatus{ID: "1", Status: UserStatusInactive, Reason: "Fired"})
+	us.CreateClassifier(context.Background(), &Classifier{Field: "1", Value: "test"})
+	us.Delete(ctx, "1")
+
+	records := sink.Records()
+	if len(records) != 4 {
+		t.Fatalf("got %d records; want 4.", len(records))
+	}
+
+	for _, r := range records {
+		if r.Time.IsZero() {
+			t.Errorf("got record %+v without time; want time set.", r)
+		}
+	}
+
+	testCases := []struct {
+		got  AuditRecord
+		want AuditRecord
+	}{
+		{
+			records[0],
+			AuditRecord{
+				Actor:    "admin",
+				Endpoint: string(createUserEndpoint),
+				Payload: map[string]interface{}{
+					"unique_field":  "123",
+					"user_name":     "Test",
+					"user_email":    "",
+					"user_password": redactedValue,
+				},
+				Status:  ReqStatusOK,
+				Message: "OK",
+			},
+		},
+		{
+			records[1],
+			AuditRecord{
+				Actor:    "admin",
+				Endpoint: string(updateUserStatusEndpoint),
+				Payload: map[string]interface{}{
+					"authorized_id": "1",
+					"status":        "25",
+					"reason":        "Fired",
+				},
+				Status:  ReqStatusOK,
+				Message: "OK",
+			},
+		},
+		{
+			records[2],
+			AuditRecord{
+				Endpoint: string(createClassifierEndpoint),
+				Payload: map[string]interface{}{
+					"field":       "1",
+					"field_value": "test",
+				},
+				Error: reqErr.Error(),
+			},
+		},
+		{
+			records[3],
+			AuditRecord{
+				Actor:    "admin",
+				Endpoint: string(deleteUserEndpoint),
+				Payload: map[string]interface{}{
+					"authorized_id": "1",
+				},
+				Status:  ReqStatusOK,
+				Message: "OK",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc.got.Time = tc.want.Time
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Errorf("got record: %+v; want %+v.", tc.got, tc.want)
+		}
+	}
+}
+
+type failingAuditSink struct{ err error }
+
+func (s failingAuditSink) Record(ctx context.Context, r AuditRecord) error {
+	return s.err
+}
+
+func TestAuditSinkError(t *testing.T) {
+	var (
+		sinkErr = errors.New("Error")
+		got     error
+	)
+
+	c := NewClient(&url.URL{}, "", nil)
+	c.common.client = requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		return nil
+	})
+	c.Use(Audit(failingAuditSink{sinkErr}, func(r AuditRecord, err error) { got = err }))
+
+	if _, err := c.User.Update(context.Background(), &User{}); err != nil {
+		t.Errorf("got error calling User.Update(): %s; want nil.", err.Error())
+	}
+
+	if got != sinkErr {
+		t.Errorf("got sink error: %v; want %v.", got, sinkErr)
+	}
+}
+
+func TestFileAuditSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liguetaxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.jsonl")
+
+	sink, err := NewFileAuditSink(path)
+	if err != nil {
+		t.Fatalf("got error calling NewFileAuditSink(): %s; want nil.", err.Error())
+	}
+
+	want := []AuditRecord{
+		{Actor: "a", Endpoint: string(createUserEndpoint), Status: ReqStatusOK},
+		{Actor: "b", Endpoint: string(updateUserEndpoint), Error: "Error"},
+	}
+	for _, r := range want {
+		if err := sink.Record(context.Background(), r); err != nil {
+			t.Fatalf("got error calling FileAuditSink.Record(): %s; want nil.", err.Error())
+		}
+	}
+	sink.Close()
+
+	f, _ := os.Open(path)
+	defer f.Close()
+
+	var got []AuditRecord
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		var r AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("got error decoding line %s: %s; want nil.", sc.Bytes(), err.Error())
+		}
+		r.Time = time.Time{}
+		got = append(got, r)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got records: %+v; want %+v.", got, want)
+	}
+
+	if _, err := NewFileAuditSink(filepath.Join(dir, "missing", "audit.jsonl")); err == nil {
+		t.Error("got error nil; want not nil.")
+	}
+}
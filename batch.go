@@ -0,0 +1,242 @@
+package liguetaxi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultBatchWorkers is the number of concurrent requests
+// used by the batch operations when none is specified.
+const DefaultBatchWorkers = 4
+
+// ErrBatchDuplicate is returned for a batch item that repeats
+// the unique field of a previous item of the same batch.
+var ErrBatchDuplicate = errors.New("liguetaxi: duplicate item in batch")
+
+// BatchOptions configures the batch operations.
+type BatchOptions struct {
+	// Workers is the maximum number of concurrent requests.
+	// If zero, DefaultBatchWorkers is used.
+	Workers int
+
+	// Retries is the number of extra attempts made for an item
+	// whose request failed. Defaults to zero, as retrying a
+	// non-idempotent operation may apply it twice.
+	Retries int
+}
+
+func (o *BatchOptions) workers() int {
+	if o == nil || o.Workers <= 0 {
+		return DefaultBatchWorkers
+	}
+	return o.Workers
+}
+
+func (o *BatchOptions) retries() int {
+	if o == nil || o.Retries < 0 {
+		return 0
+	}
+	return o.Retries
+}
+
+// BatchResult is the result of a single item of a batch operation.
+type BatchResult struct {
+	// Response is the operation response of the last attempt.
+	Response *OperationResponse
+
+	// Err is the error of the last attempt, if any.
+	Err error
+
+	// Attempts is the number of requests made for the item.
+	Attempts int
+}
+
+// CreateBatch creates the users concurrently and returns one result per user,
+// in the same order. Users repeating the unique field of a previous user
+// are not sent and fail with ErrBatchDuplicate.
+func (us *UserService) CreateBatch(ctx context.Context, users []*User, opts *BatchOptions) []BatchResult {
+	results := make([]BatchResult, len(users))
+
+	seen := make(map[string]bool, len(users))
+	for i, u := range users {
+		if u == nil || u.ID == "" {
+			continue
+		}
+		if seen[u.ID] {
+			results[i].Err = ErrBatchDuplicate
+			continue
+		}
+		seen[u.ID] = true
+	}
+
+	runBatch(ctx, results, opts, func(ctx context.Context, i int) (*OperationResponse, error) {
+		return us.Create(ctx, users[i])
+	})
+
+	return results
+}
+
+// UpdateBatch updates the users concurrently and returns one result per user,
+// in the same order.
+func (us *UserService) UpdateBatch(ctx context.Context, users []*User, opts *BatchOptions) []BatchResult {
+	results := make([]BatchResult, len(users))
+
+	runBatch(ctx, results, opts, func(ctx context.Context, i int) (*OperationResponse, error) {
+		return us.Update(ctx, users[i])
+	})
+
+	return results
+}
+
+// UpdateStatusBatch updates the users statuses concurrently and returns one
+// result per status, in the same order.
+func (us *UserService) UpdateStatusBatch(ctx context.Context, statuses []*UserStatus, opts *BatchOptions) []BatchResult {
+	results := make([]BatchResult, len(statuses))
+
+	runBatch(ctx, results, opts, func(ctx context.Context, i int) (*OperationResponse, error) {
+		return us.UpdateStatus(ctx, statuses[i])
+	})
+
+	return results
+}
+
+// ReassignResult is the result of reassigning a single user.
+type ReassignResult struct {
+	User DataUser
+	BatchResult
+}
+
+// ReassignClassifier moves all users with the value from of the classifier
+// field, e.g. "1" for Classifier1, to the value to. The users are listed
+// before any update and then updated concurrently, returning one result per
+// user. Each user is read right before its update, which sends the name,
+// email and phone read along the classifier field. The other classifier
+// fields are not returned by the reads, so they are not sent. Users listed
+// without unique field, or that cannot be read, are not updated and fail.
+// The error is returned for unknown fields or when listing the users fails.
+func (us *UserService) ReassignClassifier(ctx context.Context, field, from, to string, opts *BatchOptions) ([]ReassignResult, error) {
+	if err := (&User{}).SetClassifier(field, to); err != nil {
+		return nil, err
+	}
+
+	it := us.List(&UserFilter{ClassifierField: field, ClassifierValue: from})
+	defer it.Stop()
+
+	var listed []DataUser
+	for it.Next(ctx) {
+		listed = append(listed, it.User())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		results = make([]ReassignResult, len(listed))
+		batch   = make([]BatchResult, len(listed))
+	)
+	for i, d := range listed {
+		results[i].User = d
+		if d.UniqueField == "" {
+			batch[i].Err = fmt.Errorf("liguetaxi: user %s listed without unique field", d.ID)
+		}
+	}
+
+	runBatch(ctx, batch, opts, func(ctx context.Context, i int) (*OperationResponse, error) {
+		id := listed[i].UniqueField.String()
+
+		// The name and email are always sent, see User,
+		// so they are sent as read not to change them.
+		ur, err := us.Read(ctx, id, "")
+		if err != nil {
+			return nil, err
+		}
+		if ur.Status != ReqStatusOK {
+			return nil, fmt.Errorf("liguetaxi: reading user %s failed", id)
+		}
+
+		u := &User{ID: id, Name: ur.Data.Name.String()}
+		if ur.Data.Email != nil {
+			u.Email = ur.Data.Email.String()
+		}
+		if ur.Data.Phone != nil {
+			u.Phone = ur.Data.Phone.String()
+		}
+		u.SetClassifier(field, to)
+
+		return us.Update(ctx, u)
+	})
+
+	for i := range results {
+		results[i].BatchResult = batch[i]
+	}
+	return results, nil
+}
+
+// runBatch calls do for every result not yet failed, using a bounded
+// pool of workers. Once the context is done, the pending items are
+// not sent and fail with the context error.
+func runBatch(ctx context.Context, results []BatchResult, opts *BatchOptions, do func(ctx context.Context, i int) (*OperationResponse, error)) {
+	var (
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+		retries = opts.retries()
+	)
+
+	for w := 0; w < opts.workers() && w < len(results); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				i := i
+				results[i] = runBatchItem(ctx, retries, func() (*OperationResponse, error) {
+					return do(ctx, i)
+				})
+			}
+		}()
+	}
+
+feed:
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(results); j++ {
+				if results[j].Err == nil {
+					results[j].Err = ctx.Err()
+				}
+			}
+			break feed
+		}
+	}
+	close(jobs)
+
+	wg.Wait()
+}
+
+// runBatchItem calls do until it succeeds, the retries are
+// exhausted or the context is done.
+func runBatchItem(ctx context.Context, retries int, do func() (*OperationResponse, error)) BatchResult {
+	var res BatchResult
+
+	for res.Attempts <= retries {
+		if err := ctx.Err(); err != nil {
+			if res.Attempts == 0 {
+				res.Err = err
+			}
+			break
+		}
+
+		res.Attempts++
+		if res.Response, res.Err = do(); res.Err == nil {
+			break
+		}
+	}
+
+	return res
+}
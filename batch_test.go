@@ -0,0 +1,291 @@
+package liguetaxi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// requesterFunc is a requester safe for concurrent use in tests.
+type requesterFunc func(ctx context.Context, method string, path endpoint, body, output interface{}) error
+
+func (f requesterFunc) Request(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+	return f(ctx, method, path, body, output)
+}
+
+func TestUserBatch(t *testing.T) {
+	testCases := []struct {
+		name string
+		call func(us *UserService) []BatchResult
+		path endpoint
+	}{
+		{
+			"CreateBatch()",
+			func(us *UserService) []BatchResult {
+				return us.CreateBatch(context.Background(), []*User{{ID: "0"}, {ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}}, nil)
+			},
+			createUserEndpoint,
+		},
+		{
+			"UpdateBatch()",
+			func(us *UserService) []BatchResult {
+				return us.UpdateBatch(context.Background(), []*User{{ID: "0"}, {ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}}, nil)
+			},
+			updateUserEndpoint,
+		},
+		{
+			"UpdateStatusBatch()",
+			func(us *UserService) []BatchResult {
+				return us.UpdateStatusBatch(context.Background(), []*UserStatus{{ID: "0"}, {ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}}, nil)
+			},
+			updateUserStatusEndpoint,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // creates scoped test case
+		t.Run(tc.name, func(t *testing.T) {
+			req := requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+				if method != http.MethodPost {
+					t.Errorf("got request method: %s; want %s.", method, http.MethodPost)
+				}
+
+				if path != tc.path {
+					t.Errorf("got request path: %s; want %s.", path, tc.path)
+				}
+
+				var id string
+				switch b := body.(type) {
+				case *User:
+					id = b.ID
+				case *UserStatus:
+					id = b.ID
+				}
+				output.(*OperationResponse).Message = FlexString(id)
+				return nil
+			})
+
+			res := tc.call(&UserService{req})
+			if len(res) != 5 {
+				t.Fatalf("got %d results; want 5.", len(res))
+			}
+
+			for i, r := range res {
+				want := BatchResult{&OperationResponse{Message: FlexString(rune('0' + i))}, nil, 1}
+				if !reflect.DeepEqual(r, want) {
+					t.Errorf("got result[%d]: %+v; want %+v.", i, r, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUserBatchWorkers(t *testing.T) {
+	var running, max int32
+
+	req := requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+
+	users := make([]*User, 20)
+	for i := range users {
+		users[i] = &User{}
+	}
+
+	(&UserService{req}).UpdateBatch(context.Background(), users, &BatchOptions{Workers: 3})
+
+	if max > 3 {
+		t.Errorf("got %d concurrent requests; want at most 3.", max)
+	}
+}
+
+func TestUserBatchRetries(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls = make(map[string]int)
+		err   = errors.New("Error")
+	)
+
+	req := requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		id := body.(*User).ID
+		calls[id]++
+		// "ok" succeeds on the second attempt, "fail" never does.
+		if id == "fail" || calls[id] < 2 {
+			return err
+		}
+		return nil
+	})
+
+	res := (&UserService{req}).UpdateBatch(context.Background(), []*User{{ID: "ok"}, {ID: "fail"}}, &BatchOptions{Retries: 2})
+
+	if res[0].Err != nil || res[0].Attempts != 2 {
+		t.Errorf("got result[0] error %v after %d attempts; want nil after 2.", res[0].Err, res[0].Attempts)
+	}
+
+	if res[1].Err != err || res[1].Attempts != 3 {
+		t.Errorf("got result[1] error %v after %d attempts; want %v after 3.", res[1].Err, res[1].Attempts, err)
+	}
+}
+
+func TestUserCreateBatchDuplicate(t *testing.T) {
+	var calls int32
+
+	req := requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	res := (&UserService{req}).CreateBatch(context.Background(), []*User{{ID: "1"}, {ID: "2"}, {ID: "1"}}, nil)
+
+	if calls != 2 {
+		t.Errorf("got %d requests; want 2.", calls)
+	}
+
+	if res[2].Err != ErrBatchDuplicate || res[2].Attempts != 0 {
+		t.Errorf("got result[2] error %v after %d attempts; want %v after 0.", res[2].Err, res[2].Attempts, ErrBatchDuplicate)
+	}
+}
+
+func TestUserBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls int32
+	req := requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	users := make([]*User, 10)
+	for i := range users {
+		users[i] = &User{}
+	}
+
+	res := (&UserService{req}).UpdateBatch(ctx, users, &BatchOptions{Workers: 1})
+
+	if res[0].Err != nil {
+		t.Errorf("got result[0] error %v; want nil.", res[0].Err)
+	}
+
+	last := res[len(res)-1]
+	if last.Err != context.Canceled || last.Attempts != 0 {
+		t.Errorf("got last result error %v after %d attempts; want %v after 0.", last.Err, last.Attempts, context.Canceled)
+	}
+}
+
+func TestUserReassignClassifier(t *testing.T) {
+	var (
+		email = FlexString("silva@test.com")
+		phone = FlexString("11986548744")
+
+		mu      sync.Mutex
+		updated []string
+		query   userListQuery
+	)
+
+	req := requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		switch path {
+		case listUsersEndpoint:
+			query = body.(userListQuery)
+
+			o := output.(*UserListResponse)
+			o.Status, o.TotalPages = ReqStatusOK, 1
+			o.Data = []DataUser{
+				{ID: "1", UniqueField: "001", Name: "Joao"},
+				{ID: "2", Name: "Maria"},
+				{ID: "3", UniqueField: "003", Name: "José"},
+			}
+		case readUserEndpoint:
+			// Only the first user can be read.
+			o := output.(*UserResponse)
+			if body.(userFilter).ID == "001" {
+				o.Status = ReqStatusOK
+				o.Data = DataUser{ID: "1", UniqueField: "001", Name: "João da Silva", Email: &email, Phone: &phone}
+			}
+		case updateUserEndpoint:
+			b, err := json.Marshal(body)
+			if err != nil {
+				t.Errorf("got error marshalling body: %s; want nil.", err.Error())
+			}
+			mu.Lock()
+			updated = append(updated, string(b))
+			mu.Unlock()
+		default:
+			t.Errorf("got request path: %s; want %s, %s or %s.", path, listUsersEndpoint, readUserEndpoint, updateUserEndpoint)
+		}
+		return nil
+	})
+
+	res, err := (&UserService{req}).ReassignClassifier(context.Background(), "1", "CC-100", "CC-200", nil)
+	if err != nil {
+		t.Fatalf("got error calling User.ReassignClassifier(): %s; want nil.", err.Error())
+	}
+
+	if query.ClassifierField != "1" || query.ClassifierValue != "CC-100" {
+		t.Errorf("got users listed by classifier %s=%s; want 1=CC-100.", query.ClassifierField, query.ClassifierValue)
+	}
+
+	if len(res) != 3 {
+		t.Fatalf("got %d results; want 3.", len(res))
+	}
+
+	if res[0].User.ID != "1" || res[0].Err != nil || res[0].Attempts != 1 {
+		t.Errorf("got result[0] for user %s with error %v after %d attempts; want user 1 without error after 1.",
+			res[0].User.ID, res[0].Err, res[0].Attempts)
+	}
+
+	if res[1].User.ID != "2" || res[1].Err == nil || res[1].Attempts != 0 {
+		t.Errorf("got result[1] for user %s with error %v after %d attempts; want user 2 with error after 0.",
+			res[1].User.ID, res[1].Err, res[1].Attempts)
+	}
+
+	if res[2].User.ID != "3" || res[2].Err == nil || res[2].Attempts != 1 {
+		t.Errorf("got result[2] for user %s with error %v after %d attempts; want user 3 with error after 1.",
+			res[2].User.ID, res[2].Err, res[2].Attempts)
+	}
+
+	// The name, email and phone are sent as read, not as listed.
+	want := []string{
+		`{"unique_field":"001","user_name":"João da Silva","user_email":"silva@test.com",` +
+			`"user_phone":"11986548744","classificador1":"CC-200"}`,
+	}
+	if !reflect.DeepEqual(updated, want) {
+		t.Errorf("got users updated with %q; want %q.", updated, want)
+	}
+}
+
+func TestUserReassignClassifierError(t *testing.T) {
+	err := errors.New("Error")
+
+	req := requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		return err
+	})
+
+	us := &UserService{req}
+	if _, e := us.ReassignClassifier(context.Background(), "1", "CC-100", "CC-200", nil); e != err {
+		t.Errorf("got error: %v; want %v.", e, err)
+	}
+
+	if _, e := us.ReassignClassifier(context.Background(), "21", "CC-100", "CC-200", nil); e == nil {
+		t.Error("got nil error for unknown classifier field; want error.")
+	}
+}
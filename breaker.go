@@ -0,0 +1,208 @@
+package liguetaxi
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Circuit breaker defaults.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned, without calling the API,
+// for requests to an endpoint whose circuit is open.
+var ErrCircuitOpen = errors.New("liguetaxi: circuit breaker is open")
+
+// CircuitState is the state of an endpoint's circuit breaker.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerSettings configures a circuit breaker.
+type BreakerSettings struct {
+	// Threshold is the number of consecutive transport errors or
+	// 5xx responses that opens the circuit. If zero,
+	// DefaultBreakerThreshold is used.
+	Threshold int
+
+	// Cooldown is how long the circuit stays open before a single
+	// probe request is let through. If zero,
+	// DefaultBreakerCooldown is used.
+	Cooldown time.Duration
+
+	// OnStateChange, if not nil, is called whenever the
+	// circuit of an endpoint changes its state.
+	OnStateChange func(endpoint string, from, to CircuitState)
+}
+
+func (s BreakerSettings) threshold() int {
+	if s.Threshold <= 0 {
+		return DefaultBreakerThreshold
+	}
+	return s.Threshold
+}
+
+func (s BreakerSettings) cooldown() time.Duration {
+	if s.Cooldown <= 0 {
+		return DefaultBreakerCooldown
+	}
+	return s.Cooldown
+}
+
+// EnableCircuitBreaker makes the client fail fast with ErrCircuitOpen while
+// an endpoint is failing. Every endpoint has its own circuit per host, e.g.
+// per host set by WithHost, configured by settings unless overridden in
+// perEndpoint, which is keyed by the endpoint path without the response
+// type, e.g. "user/check_authorized".
+//
+// EnableCircuitBreaker must be called before the client is used.
+func (c *Client) EnableCircuitBreaker(settings BreakerSettings, perEndpoint map[string]BreakerSettings) {
+	c.breakers = &breakerSet{
+		settings:    settings,
+		perEndpoint: perEndpoint,
+		breakers:    make(map[breakerKey]*circuitBreaker),
+	}
+}
+
+// breakerKey identifies the circuit breaker of an endpoint of a host.
+type breakerKey struct {
+	host string
+	path endpoint
+}
+
+// breakerSet holds the circuit breakers of the endpoints.
+type breakerSet struct {
+	mu          sync.Mutex
+	settings    BreakerSettings
+	perEndpoint map[string]BreakerSettings
+	breakers    map[breakerKey]*circuitBreaker
+}
+
+// get returns the circuit breaker of the endpoint of
+// the host, creating it on the first call.
+func (bs *breakerSet) get(host string, path endpoint) *circuitBreaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	key := breakerKey{host, path}
+	if b, ok := bs.breakers[key]; ok {
+		return b
+	}
+
+	settings, ok := bs.perEndpoint[string(path)]
+	if !ok {
+		settings = bs.settings
+	}
+
+	b := &circuitBreaker{endpoint: string(path), settings: settings, now: time.Now}
+	bs.breakers[key] = b
+	return b
+}
+
+// circuitBreaker is the circuit breaker of a single endpoint.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	endpoint string
+	settings BreakerSettings
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+// allow returns ErrCircuitOpen if the request must not be sent.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.settings.cooldown() {
+			return ErrCircuitOpen
+		}
+		b.setState(CircuitHalfOpen)
+		b.probing = true
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+
+	return nil
+}
+
+// record updates the circuit with the result of an allowed request.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !failed {
+		b.failures = 0
+		if b.state != CircuitClosed {
+			b.setState(CircuitClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.settings.threshold() {
+		b.openedAt = b.now()
+		if b.state != CircuitOpen {
+			b.setState(CircuitOpen)
+		}
+	}
+}
+
+// release ends an allowed request without recording its result.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// setState changes the state and calls the callback. The callback
+// is called holding the lock, so it must not use the client.
+func (b *circuitBreaker) setState(to CircuitState) {
+	from := b.state
+	b.state = to
+
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(b.endpoint, from, to)
+	}
+}
+
+// isFailure reports whether the response or error of a request
+// must be counted as a backend failure by the circuit breaker.
+func isFailure(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,162 @@
+package liguetaxi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitStateString(t *testing.T) {
+	testCases := []struct {
+		state CircuitState
+		want  string
+	}{
+		{CircuitClosed, "closed"},
+		{CircuitOpen, "open"},
+		{CircuitHalfOpen, "half-open"},
+		{CircuitState(10), "unknown"},
+	}
+
+	for _, tc := range testCases {
+		if s := tc.state.String(); s != tc.want {
+			t.Errorf("got CircuitState.String(): %s; want %s.", s, tc.want)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		now         = time.Now()
+		transitions []CircuitState
+	)
+
+	b := &circuitBreaker{
+		endpoint: "test",
+		settings: BreakerSettings{
+			Threshold: 2,
+			Cooldown:  time.Minute,
+			OnStateChange: func(endpoint string, from, to CircuitState) {
+				if endpoint != "test" {
+					t.Errorf("got endpoint: %s; want test.", endpoint)
+				}
+				transitions = append(transitions, to)
+			},
+		},
+		now: func() time.Time { return now },
+	}
+
+	mustAllow := func(want error) {
+		t.Helper()
+		if err := b.allow(); err != want {
+			t.Fatalf("got circuitBreaker.allow(): %v; want %v.", err, want)
+		}
+	}
+
+	mustAllow(nil)
+	b.record(true)
+	mustAllow(nil)
+	b.record(true)
+
+	if b.state != CircuitOpen {
+		t.Fatalf("got state %s; want %s.", b.state, CircuitOpen)
+	}
+	mustAllow(ErrCircuitOpen)
+
+	// After the cooldown only one probe is allowed.
+	now = now.Add(time.Minute)
+	mustAllow(nil)
+	mustAllow(ErrCircuitOpen)
+
+	// A failed probe opens the circuit again.
+	b.record(true)
+	mustAllow(ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	mustAllow(nil)
+	b.record(false)
+	mustAllow(nil)
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("got transitions: %v; want %v.", transitions, want)
+	}
+}
+
+func TestCircuitBreakerSuccessResets(t *testing.T) {
+	b := &circuitBreaker{settings: BreakerSettings{Threshold: 2}, now: time.Now}
+
+	b.record(true)
+	b.record(false)
+	b.record(true)
+
+	if b.state != CircuitClosed {
+		t.Errorf("got state %s; want %s.", b.state, CircuitClosed)
+	}
+}
+
+func TestBreakerSetPerEndpoint(t *testing.T) {
+	c := NewClient(&url.URL{}, "", nil)
+	c.EnableCircuitBreaker(BreakerSettings{Threshold: 3}, map[string]BreakerSettings{
+		string(readUserEndpoint): {Threshold: 1},
+	})
+
+	if th := c.breakers.get("", readUserEndpoint).settings.threshold(); th != 1 {
+		t.Errorf("got threshold %d for %s; want 1.", th, readUserEndpoint)
+	}
+
+	if th := c.breakers.get("", updateUserEndpoint).settings.threshold(); th != 3 {
+		t.Errorf("got threshold %d for %s; want 3.", th, updateUserEndpoint)
+	}
+
+	if b := c.breakers.get("", readUserEndpoint); b != c.breakers.get("", readUserEndpoint) {
+		t.Error("got different breakers for the same endpoint; want the same.")
+	}
+}
+
+func TestClientRequestCircuitBreaker(t *testing.T) {
+	var hits int32
+
+	s := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := NewClient(u, "abc", nil)
+	c.EnableCircuitBreaker(BreakerSettings{Threshold: 2, Cooldown: time.Hour}, nil)
+
+	for i := 0; i < 2; i++ {
+		if err := c.Request(context.Background(), http.MethodPost, "foo", nil, &dummy{}); err == ErrCircuitOpen {
+			t.Fatalf("got error %v on request %d; want API error.", err, i)
+		}
+	}
+
+	if err := c.Request(context.Background(), http.MethodPost, "foo", nil, &dummy{}); err != ErrCircuitOpen {
+		t.Errorf("got error: %v; want %v.", err, ErrCircuitOpen)
+	}
+
+	// Other endpoints have their own circuit.
+	if err := c.Request(context.Background(), http.MethodPost, "bar", nil, &dummy{}); err == ErrCircuitOpen {
+		t.Errorf("got error %v; want API error.", err)
+	}
+
+	if hits != 3 {
+		t.Errorf("got %d requests to the server; want 3.", hits)
+	}
+
+	// Other hosts have their own circuit.
+	other := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"Testing"}`))
+	})
+	defer other.Close()
+
+	ou, _ := url.Parse(other.URL)
+	if err := c.Request(WithHost(context.Background(), ou), http.MethodPost, "foo", nil, &dummy{}); err != nil {
+		t.Errorf("got error %v from another host; want nil.", err)
+	}
+}
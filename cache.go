@@ -0,0 +1,384 @@
+package liguetaxi
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the number of entries held by the
+// cache created by EnableCache when none is given.
+const DefaultCacheSize = 1024
+
+// Cache is the storage used by the Client to keep read responses.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored for key, if not expired.
+	Get(key string) (interface{}, bool)
+
+	// Set stores the value for key. A non-positive ttl means
+	// the value never expires.
+	Set(key string, value interface{}, ttl time.Duration)
+
+	// Delete removes the value stored for key.
+	Delete(key string)
+}
+
+// LRUCache is an in-memory Cache that evicts the least
+// recently used entry when full.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// NewLRUCache returns an LRUCache holding up to size entries.
+func NewLRUCache(size int) *LRUCache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+
+	return &LRUCache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get implements the Cache interface.
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// Set implements the Cache interface.
+func (c *LRUCache) Set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.ll.PushFront(&lruEntry{key, value, expires})
+
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Delete implements the Cache interface.
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *LRUCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry).key)
+}
+
+// EnableCache makes the client cache the responses of UserService.Read
+// and UserService.ReadClassifier. Successful responses are kept for ttl
+// and responses for users or classifiers not found for negativeTTL;
+// a non-positive duration disables the caching of that kind of response.
+// Entries are invalidated when the user or classifier is created or
+// updated through the same client. If cache is nil, an LRUCache of
+// DefaultCacheSize entries is used.
+//
+// EnableCache must be called before the client is used.
+func (c *Client) EnableCache(cache Cache, ttl, negativeTTL time.Duration) {
+	if cache == nil {
+		cache = NewLRUCache(DefaultCacheSize)
+	}
+
+	c.common.client = &cachingRequester{
+		next:        c.common.client,
+		cache:       cache,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+// cachingRequester is the requester that serves the read requests
+// from the cache, falling back to the next requester.
+type cachingRequester struct {
+	next        requester
+	cache       Cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	// mu guards changed and pruned.
+	mu sync.Mutex
+	// changed holds when the status of the users changed, by
+	// authorizedCacheKey. Users are cached by their unique field,
+	// so the cached users read before are found stale on Get,
+	// regardless of what the cache evicted.
+	changed map[string]time.Time
+	pruned  time.Time
+}
+
+// cachedUser is the cached response of a user read, along
+// with the user name used on the request.
+type cachedUser struct {
+	name string
+	res  UserResponse
+	// read is when the response was requested.
+	read time.Time
+}
+
+// The cache keys are scoped by the host and token
+// set in the context, see contextScope.
+
+func userCacheKey(scope, id string) string {
+	return scope + "/user:" + id
+}
+
+func authorizedCacheKey(scope, id string) string {
+	return scope + "/authorized:" + id
+}
+
+func classifierCacheKey(scope, field, value string) string {
+	return scope + "/classifier:" + field + ":" + value
+}
+
+func classifierIDCacheKey(scope, id string) string {
+	return scope + "/classifier_id:" + id
+}
+
+// Request implements the requester interface.
+func (cr *cachingRequester) Request(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+	switch path {
+	case readUserEndpoint:
+		if f, ok := body.(userFilter); ok {
+			if res, ok := output.(*UserResponse); ok {
+				return cr.readUser(ctx, method, f, res)
+			}
+		}
+	case readClassifierEndpoint:
+		if f, ok := body.(classifierFilter); ok {
+			if res, ok := output.(*ClassifierResponse); ok {
+				return cr.readClassifier(ctx, method, f, res)
+			}
+		}
+	}
+
+	if err := cr.next.Request(ctx, method, path, body, output); err != nil {
+		return err
+	}
+
+	cr.invalidate(ctx, path, body)
+	return nil
+}
+
+func (cr *cachingRequester) readUser(ctx context.Context, method string, f userFilter, res *UserResponse) error {
+	scope := contextScope(ctx)
+	key := userCacheKey(scope, f.ID)
+
+	if v, ok := cr.cache.Get(key); ok {
+		if cu := v.(*cachedUser); cu.name == f.Name && !cr.changedSince(scope, cu) {
+			*res = cu.res.clone()
+			return nil
+		}
+	}
+
+	read := time.Now()
+	if err := cr.next.Request(ctx, method, readUserEndpoint, f, res); err != nil {
+		return err
+	}
+
+	ttl := cr.ttl
+	if res.Status != ReqStatusOK {
+		ttl = cr.negativeTTL
+	}
+
+	// Users whose status changed while being read are not stored.
+	if cu := (&cachedUser{f.Name, res.clone(), read}); !cr.changedSince(scope, cu) {
+		cr.set(key, cu, ttl)
+	}
+
+	return nil
+}
+
+func (cr *cachingRequester) readClassifier(ctx context.Context, method string, f classifierFilter, res *ClassifierResponse) error {
+	key := classifierCacheKey(contextScope(ctx), f.Field, f.Value)
+
+	if v, ok := cr.cache.Get(key); ok {
+		*res = v.(ClassifierResponse).clone()
+		return nil
+	}
+
+	if err := cr.next.Request(ctx, method, readClassifierEndpoint, f, res); err != nil {
+		return err
+	}
+
+	ttl := cr.ttl
+	if res.Status != ReqStatusOK {
+		ttl = cr.negativeTTL
+	}
+	cr.set(key, res.clone(), ttl)
+
+	// Updates are keyed by the field ID.
+	for _, c := range res.Data {
+		if c.ID != "" {
+			cr.set(classifierIDCacheKey(contextScope(ctx), c.ID), key, cr.ttl)
+		}
+	}
+
+	return nil
+}
+
+// set stores the value in the cache, unless ttl is not positive.
+func (cr *cachingRequester) set(key string, value interface{}, ttl time.Duration) {
+	if ttl > 0 {
+		cr.cache.Set(key, value, ttl)
+	}
+}
+
+// invalidate removes the cached responses affected
+// by a successful request to path.
+func (cr *cachingRequester) invalidate(ctx context.Context, path endpoint, body interface{}) {
+	scope := contextScope(ctx)
+
+	switch path {
+	case createUserEndpoint, updateUserEndpoint:
+		if u, ok := body.(*User); ok && u != nil && u.ID != "" {
+			cr.cache.Delete(userCacheKey(scope, u.ID))
+		}
+	case updateUserStatusEndpoint:
+		if s, ok := body.(*UserStatus); ok && s != nil {
+			cr.invalidateAuthorized(scope, s.ID)
+		}
+	case deleteUserEndpoint:
+		if d, ok := body.(userDelete); ok {
+			cr.invalidateAuthorized(scope, d.ID)
+		}
+	case createClassifierEndpoint:
+		if c, ok := body.(*Classifier); ok && c != nil {
+			cr.cache.Delete(classifierCacheKey(scope, c.Field, c.Value))
+		}
+	case updateClassifierEndpoint:
+		if c, ok := body.(*Classifier); ok && c != nil {
+			// Both the previous value, if renamed, and the new one.
+			idKey := classifierIDCacheKey(scope, c.ID)
+			if key, ok := cr.cache.Get(idKey); ok {
+				cr.cache.Delete(key.(string))
+				cr.cache.Delete(idKey)
+			}
+			cr.cache.Delete(classifierCacheKey(scope, c.Field, c.Value))
+		}
+	case deleteClassifierEndpoint:
+		if f, ok := body.(classifierFilter); ok {
+			cr.cache.Delete(classifierCacheKey(scope, f.Field, f.Value))
+		}
+	}
+}
+
+// invalidateAuthorized makes the cached user with the authorized ID stale.
+func (cr *cachingRequester) invalidateAuthorized(scope, id string) {
+	now := time.Now()
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if cr.changed == nil {
+		cr.changed = make(map[string]time.Time)
+	}
+
+	// Users read before the changes older than the TTL have expired,
+	// twice the TTL leaving room for the users stored while changed.
+	if now.Sub(cr.pruned) > cr.ttl {
+		for k, at := range cr.changed {
+			if now.Sub(at) > 2*cr.ttl {
+				delete(cr.changed, k)
+			}
+		}
+		cr.pruned = now
+	}
+
+	cr.changed[authorizedCacheKey(scope, id)] = now
+}
+
+// changedSince reports whether the status of the
+// cached user changed since it was read.
+func (cr *cachingRequester) changedSince(scope string, cu *cachedUser) bool {
+	if cu.res.Data.ID == "" {
+		return false
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	at, ok := cr.changed[authorizedCacheKey(scope, cu.res.Data.ID.String())]
+	return ok && !cu.read.After(at)
+}
+
+// clone returns a copy of the response not sharing its
+// pointers, so cached responses are not modified by callers.
+func (r UserResponse) clone() UserResponse {
+	d := &r.Data
+	if d.Email != nil {
+		email := *d.Email
+		d.Email = &email
+	}
+	if d.Phone != nil {
+		phone := *d.Phone
+		d.Phone = &phone
+	}
+	if d.Status != nil {
+		status := *d.Status
+		d.Status = &status
+	}
+	return r
+}
+
+// clone returns a copy of the response not sharing its
+// classifier fields, so cached responses are not modified by callers.
+func (r ClassifierResponse) clone() ClassifierResponse {
+	if r.Data != nil {
+		r.Data = append([]Classifier(nil), r.Data...)
+	}
+	return r
+}
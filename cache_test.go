@@ -0,0 +1,353 @@
+package liguetaxi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2)
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+
+	// Touches "a" so "b" is the least recently used.
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("got LRUCache.Get(a): %v, %t; want 1, true.", v, ok)
+	}
+
+	c.Set("c", 3, 0)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("got LRUCache.Get(b) ok; want evicted.")
+	}
+
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("got LRUCache.Get(c): %v, %t; want 3, true.", v, ok)
+	}
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("got LRUCache.Get(a) ok; want deleted.")
+	}
+
+	if l := c.Len(); l != 1 {
+		t.Errorf("got LRUCache.Len(): %d; want 1.", l)
+	}
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	c := NewLRUCache(0)
+
+	c.Set("a", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("got LRUCache.Get(a) ok; want expired.")
+	}
+
+	if l := c.Len(); l != 0 {
+		t.Errorf("got LRUCache.Len(): %d; want 0.", l)
+	}
+}
+
+func TestClientEnableCache(t *testing.T) {
+	c := NewClient(&url.URL{}, "", nil)
+	c.EnableCache(nil, time.Minute, time.Minute)
+
+	cr, ok := c.common.client.(*cachingRequester)
+	if !ok {
+		t.Fatalf("got requester %T; want *cachingRequester.", c.common.client)
+	}
+
+	if cr.next != c {
+		t.Errorf("got next requester %+v; want the client.", cr.next)
+	}
+
+	if _, ok := cr.cache.(*LRUCache); !ok {
+		t.Errorf("got cache %T; want *LRUCache.", cr.cache)
+	}
+}
+
+// countingRequester returns a requester counting the requests
+// by path and answering them with the given response status.
+func countingRequester(calls map[endpoint]int, status ReqStatus) requester {
+	return requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		calls[path]++
+
+		switch o := output.(type) {
+		case *UserResponse:
+			*o = UserResponse{Status: status}
+			if status == ReqStatusOK {
+				o.Data.ID = FlexString("auth-" + body.(userFilter).ID)
+			}
+		case *ClassifierResponse:
+			*o = ClassifierResponse{Status: status}
+			if f := body.(classifierFilter); status == ReqStatusOK {
+				o.Data = []Classifier{{ID: "id-" + f.Value, Field: f.Field, Value: f.Value}}
+			}
+		}
+		return nil
+	})
+}
+
+func TestCachingRequester(t *testing.T) {
+	calls := make(map[endpoint]int)
+	us := &UserService{&cachingRequester{
+		next:        countingRequester(calls, ReqStatusOK),
+		cache:       NewLRUCache(10),
+		ttl:         time.Minute,
+		negativeTTL: time.Minute,
+	}}
+	ctx := context.Background()
+
+	read := func() *UserResponse {
+		u, err := us.Read(ctx, "123", "")
+		if err != nil {
+			t.Fatalf("got error calling User.Read(): %s; want nil.", err.Error())
+		}
+		return u
+	}
+
+	first := read()
+	if second := read(); !reflect.DeepEqual(first, second) {
+		t.Errorf("got cached response %+v; want %+v.", second, first)
+	}
+
+	if n := calls[readUserEndpoint]; n != 1 {
+		t.Errorf("got %d read requests; want 1.", n)
+	}
+
+	// A different name must not be served from the cache.
+	us.Read(ctx, "123", "test")
+	if n := calls[readUserEndpoint]; n != 2 {
+		t.Errorf("got %d read requests; want 2.", n)
+	}
+
+	us.Update(ctx, &User{ID: "123"})
+	read()
+	if n := calls[readUserEndpoint]; n != 3 {
+		t.Errorf("got %d read requests after User.Update(); want 3.", n)
+	}
+
+	us.UpdateStatus(ctx, &UserStatus{ID: "auth-123"})
+	read()
+	if n := calls[readUserEndpoint]; n != 4 {
+		t.Errorf("got %d read requests after User.UpdateStatus(); want 4.", n)
+	}
+
+	us.Delete(ctx, "auth-123")
+	read()
+	if n := calls[readUserEndpoint]; n != 5 {
+		t.Errorf("got %d read requests after User.Delete(); want 5.", n)
+	}
+
+	us.ReadClassifier(ctx, "1", "test")
+	us.ReadClassifier(ctx, "1", "test")
+	if n := calls[readClassifierEndpoint]; n != 1 {
+		t.Errorf("got %d classifier read requests; want 1.", n)
+	}
+
+	us.CreateClassifier(ctx, &Classifier{Field: "1", Value: "test"})
+	us.ReadClassifier(ctx, "1", "test")
+	if n := calls[readClassifierEndpoint]; n != 2 {
+		t.Errorf("got %d classifier read requests after User.CreateClassifier(); want 2.", n)
+	}
+
+	us.DeleteClassifier(ctx, "1", "test")
+	us.ReadClassifier(ctx, "1", "test")
+	if n := calls[readClassifierEndpoint]; n != 3 {
+		t.Errorf("got %d classifier read requests after User.DeleteClassifier(); want 3.", n)
+	}
+}
+
+func TestCachingRequesterUpdateClassifier(t *testing.T) {
+	calls := make(map[endpoint]int)
+	us := &UserService{&cachingRequester{
+		next:        countingRequester(calls, ReqStatusOK),
+		cache:       NewLRUCache(10),
+		ttl:         time.Minute,
+		negativeTTL: time.Minute,
+	}}
+	ctx := context.Background()
+
+	us.ReadClassifier(ctx, "1", "old")
+	us.ReadClassifier(ctx, "1", "new")
+
+	// Renames the value old to new.
+	us.UpdateClassifier(ctx, &Classifier{ID: "id-old", Field: "1", Value: "new"})
+
+	us.ReadClassifier(ctx, "1", "old")
+	us.ReadClassifier(ctx, "1", "new")
+	if n := calls[readClassifierEndpoint]; n != 4 {
+		t.Errorf("got %d classifier read requests after User.UpdateClassifier(); want 4.", n)
+	}
+}
+
+func TestCachingRequesterNegative(t *testing.T) {
+	testCases := []struct {
+		negativeTTL time.Duration
+		want        int
+	}{
+		{time.Minute, 1},
+		{0, 2},
+	}
+
+	for _, tc := range testCases {
+		calls := make(map[endpoint]int)
+		us := &UserService{&cachingRequester{
+			next:        countingRequester(calls, ReqStatusFail),
+			cache:       NewLRUCache(10),
+			ttl:         time.Minute,
+			negativeTTL: tc.negativeTTL,
+		}}
+
+		us.Read(context.Background(), "123", "")
+		us.Read(context.Background(), "123", "")
+
+		if n := calls[readUserEndpoint]; n != tc.want {
+			t.Errorf("got %d read requests with negative TTL %s; want %d.", n, tc.negativeTTL, tc.want)
+		}
+
+		us.Create(context.Background(), &User{ID: "123"})
+		us.Read(context.Background(), "123", "")
+
+		if n := calls[readUserEndpoint]; n != tc.want+1 {
+			t.Errorf("got %d read requests after User.Create(); want %d.", n, tc.want+1)
+		}
+	}
+}
+
+func TestCachingRequesterError(t *testing.T) {
+	var calls int
+	err := errors.New("Error")
+
+	us := &UserService{&cachingRequester{
+		next: requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+			calls++
+			return err
+		}),
+		cache: NewLRUCache(10),
+		ttl:   time.Minute,
+	}}
+
+	for i := 0; i < 2; i++ {
+		if _, e := us.Read(context.Background(), "123", ""); e != err {
+			t.Errorf("got error: %v; want %v.", e, err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("got %d requests; want 2.", calls)
+	}
+}
+
+func TestCachingRequesterScope(t *testing.T) {
+	calls := make(map[endpoint]int)
+	us := &UserService{&cachingRequester{
+		next:  countingRequester(calls, ReqStatusOK),
+		cache: NewLRUCache(10),
+		ttl:   time.Minute,
+	}}
+
+	us.Read(context.Background(), "123", "")
+	us.Read(WithToken(context.Background(), "other"), "123", "")
+	us.Read(WithToken(context.Background(), "other"), "123", "")
+
+	if n := calls[readUserEndpoint]; n != 2 {
+		t.Errorf("got %d read requests; want 2.", n)
+	}
+}
+
+func TestCachingRequesterEviction(t *testing.T) {
+	testCases := []struct {
+		name   string
+		change func(ctx context.Context, us *UserService)
+		want   ReqStatus
+	}{
+		{"User.Deactivate()", func(ctx context.Context, us *UserService) { us.Deactivate(ctx, "auth-1", "") }, ReqStatusOK},
+		{"User.Delete()", func(ctx context.Context, us *UserService) { us.Delete(ctx, "auth-1") }, ReqStatusFail},
+	}
+
+	for _, tc := range testCases {
+		var changed bool
+		us := &UserService{&cachingRequester{
+			next: requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+				o, ok := output.(*UserResponse)
+				if !ok {
+					changed = true
+					return nil
+				}
+
+				id := body.(userFilter).ID
+				switch {
+				case !changed || id != "1":
+					*o = UserResponse{Status: ReqStatusOK, Data: DataUser{ID: FlexString("auth-" + id), Status: UserStatusActive.New()}}
+				case tc.want == ReqStatusOK:
+					*o = UserResponse{Status: ReqStatusOK, Data: DataUser{ID: "auth-1", Status: UserStatusInactive.New()}}
+				default:
+					*o = UserResponse{Status: ReqStatusFail}
+				}
+				return nil
+			}),
+			cache: NewLRUCache(3),
+			ttl:   time.Minute,
+		}}
+		ctx := context.Background()
+
+		// The hits on user 1 and the read of user 2 put
+		// the cache under pressure before the change.
+		us.Read(ctx, "1", "")
+		us.Read(ctx, "1", "")
+		us.Read(ctx, "2", "")
+
+		tc.change(ctx, us)
+
+		u, _ := us.Read(ctx, "1", "")
+		if u.Status != tc.want || u.Data.IsActive() {
+			t.Errorf("got user %+v after %s; want status %s and not active.", u, tc.name, tc.want)
+		}
+	}
+}
+
+func TestCachingRequesterCopy(t *testing.T) {
+	calls := make(map[endpoint]int)
+	us := &UserService{&cachingRequester{
+		next: requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+			calls[path]++
+			switch o := output.(type) {
+			case *UserResponse:
+				email := FlexString("test@example.com")
+				*o = UserResponse{Status: ReqStatusOK, Data: DataUser{Email: &email, Status: UserStatusActive.New()}}
+			case *ClassifierResponse:
+				*o = ClassifierResponse{Status: ReqStatusOK, Data: []Classifier{{Field: "1", Value: "test"}}}
+			}
+			return nil
+		}),
+		cache: NewLRUCache(10),
+		ttl:   time.Minute,
+	}}
+	ctx := context.Background()
+
+	u, _ := us.Read(ctx, "123", "")
+	*u.Data.Status, *u.Data.Email = UserStatusSynching, "changed"
+
+	if u, _ = us.Read(ctx, "123", ""); !u.Data.IsActive() || *u.Data.Email != "test@example.com" {
+		t.Errorf("got cached user %s, %s; want Active, test@example.com.", *u.Data.Status, *u.Data.Email)
+	}
+
+	c, _ := us.ReadClassifier(ctx, "1", "test")
+	c.Data[0].Value = "changed"
+
+	if c, _ = us.ReadClassifier(ctx, "1", "test"); c.Data[0].Value != "test" {
+		t.Errorf("got cached classifier value %s; want test.", c.Data[0].Value)
+	}
+
+	if calls[readUserEndpoint] != 1 || calls[readClassifierEndpoint] != 1 {
+		t.Errorf("got %d user and %d classifier read requests; want 1 and 1.", calls[readUserEndpoint], calls[readClassifierEndpoint])
+	}
+}
@@ -4,30 +4,92 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
-// reqStatus is the request status.
+// ReqStatus is the request status.
 // Success = 1
 // Fail = 0
-type reqStatus int
+type ReqStatus int
 
 const (
 	// Request statuses.
-	ReqStatusFail reqStatus = iota
+	ReqStatusFail ReqStatus = iota
 	ReqStatusOK
 
 	// Error message format.
 	errFmt = `Error while request the LigueTaxi API: %s; Status Code: %d; Body: %s.`
+
+	// DefaultMaxResponseSize is the maximum size of
+	// a response body read by default.
+	DefaultMaxResponseSize = 32 << 20
+
+	// Number of bytes of the response body kept in
+	// the ApiError for diagnostics.
+	errBodySize = 4 << 10
 )
 
+// String returns the name of the request status.
+func (s ReqStatus) String() string {
+	switch s {
+	case ReqStatusOK:
+		return "OK"
+	case ReqStatusFail:
+		return "Fail"
+	}
+	return strconv.Itoa(int(s))
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// ReqStatus type. The status is accepted either as a
+// number or as a string holding a number.
+func (s *ReqStatus) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	if n == "" {
+		return nil
+	}
+	return s.UnmarshalText([]byte(n))
+}
+
+// MarshalJSON implements the Marshaler interface for
+// ReqStatus type.
+func (s ReqStatus) MarshalJSON() ([]byte, error) {
+	return s.MarshalText()
+}
+
+// UnmarshalText implements the TextUnmarshaler interface for
+// ReqStatus type.
+func (s *ReqStatus) UnmarshalText(b []byte) error {
+	i, err := strconv.Atoi(string(b))
+	if err != nil {
+		return err
+	}
+	*s = ReqStatus(i)
+	return nil
+}
+
+// MarshalText implements the TextMarshaler interface for
+// ReqStatus type.
+func (s ReqStatus) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(int(s))), nil
+}
+
+// ErrResponseTooLarge is wrapped by the ApiError returned when
+// the response body exceeds the client's maximum response size.
+var ErrResponseTooLarge = errors.New("liguetaxi: response body too large")
+
 // status is the request status.
 type Status struct {
-	Status reqStatus `json:"status"`
+	Status ReqStatus `json:"status"`
 }
 
 // ApiError implements the error interface
@@ -36,12 +98,20 @@ type ApiError struct {
 	statusCode int
 	body       []byte
 	msg        string
+	err        error
 }
 
 func (e *ApiError) Error() string {
 	return fmt.Sprintf(errFmt, e.msg, e.statusCode, e.body)
 }
 
+// Unwrap returns the error that caused the ApiError:
+// ErrResponseTooLarge, the error reading the response
+// body or the error decoding it.
+func (e *ApiError) Unwrap() error {
+	return e.err
+}
+
 // requester is the interface that performs a request
 // to the server and parses the payload.
 type requester interface {
@@ -56,11 +126,33 @@ type Client struct {
 	// client is the http client.
 	client *http.Client
 
+	// flight coalesces concurrent identical reads.
+	flight flightGroup
+
+	// breakers are the endpoints' circuit breakers,
+	// nil if disabled.
+	breakers *breakerSet
+
+	// maxResponseSize is the maximum size of a response body.
+	maxResponseSize int64
+
+	// location is the time zone of the times sent by the
+	// API without zone. If nil, America/Sao_Paulo.
+	location *time.Location
+
 	common service
 
 	// User is the service that handles http logic for requests
 	// related to the user.
 	User *UserService
+
+	// Ride is the service that handles http logic for requests
+	// related to the rides.
+	Ride *RideService
+
+	// Report is the service that handles http logic for requests
+	// related to the reports.
+	Report *ReportService
 }
 
 type service struct {
@@ -69,31 +161,47 @@ type service struct {
 
 // New returns a Client for requests Ligue Taxi API.
 func NewClient(host *url.URL, token string, client *http.Client) *Client {
+	return newClient(host, &Transport{Token: token}, client)
+}
+
+// NewClientWithTokenSource returns a Client for requests Ligue Taxi API
+// which consults the TokenSource for the token on every request.
+func NewClientWithTokenSource(host *url.URL, src TokenSource, client *http.Client) *Client {
+	return newClient(host, &Transport{Source: src}, client)
+}
+
+func newClient(host *url.URL, tr *Transport, client *http.Client) *Client {
 	if client == nil {
 		client = &http.Client{}
 	}
-	client.Transport = &Transport{
-		token,
-		client.Transport,
-	}
+	tr.Base = client.Transport
+	client.Transport = tr
 
-	c := &Client{host: host, client: client}
+	c := &Client{host: host, client: client, maxResponseSize: DefaultMaxResponseSize}
 
 	c.common.client = c
 
 	c.User = (*UserService)(&c.common)
+	c.Ride = (*RideService)(&c.common)
+	c.Report = (*ReportService)(&c.common)
 	return c
 }
 
 // Request created an API request. A relative path can be providaded
-// in which case it is resolved relative to the host of the Client.
+// in which case it is resolved relative to the host of the Client,
+// or to the host set in the context with WithHost.
 func (c *Client) Request(ctx context.Context, method string, path endpoint, body, output interface{}) error {
-	u, err := c.host.Parse(path.String(ctx))
+	host := c.host
+	if h, ok := contextHost(ctx); ok {
+		host = h
+	}
+
+	u, err := host.Parse(path.String(ctx))
 	if err != nil {
 		return err
 	}
 
-	var b io.ReadWriter
+	var b *bytes.Buffer
 	if body != nil {
 		b = new(bytes.Buffer)
 		if err := json.NewEncoder(b).Encode(body); err != nil {
@@ -101,6 +209,53 @@ func (c *Client) Request(ctx context.Context, method string, path endpoint, body
 		}
 	}
 
+	if !coalescedEndpoints[path] {
+		return c.do(ctx, method, path, u, b, output)
+	}
+
+	key := fmt.Sprintf("%s %s %T %s %v %s", method, u, output, contextScope(ctx), contextHeader(ctx), b)
+	return c.flight.do(ctx, key, output, func(ctx context.Context, output interface{}) error {
+		return c.do(ctx, method, path, u, b, output)
+	})
+}
+
+// send sends the request through the endpoint's circuit breaker, if enabled.
+func (c *Client) send(req *http.Request, path endpoint) (*http.Response, error) {
+	if c.breakers == nil {
+		return c.client.Do(req)
+	}
+
+	b := c.breakers.get(req.URL.Host, path)
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+
+	res, err := c.client.Do(req)
+	// Requests canceled by the caller say nothing about the backend.
+	if err != nil && req.Context().Err() != nil {
+		b.release()
+	} else {
+		b.record(isFailure(res, err))
+	}
+
+	return res, err
+}
+
+// do sends the request and decodes the response body into output.
+func (c *Client) do(ctx context.Context, method string, path endpoint, u *url.URL, body *bytes.Buffer, output interface{}) error {
+	if d, ok := contextTimeout(ctx); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	// A nil *bytes.Buffer must not be passed as
+	// a non-nil io.Reader to http.NewRequest.
+	var b io.Reader
+	if body != nil {
+		b = body
+	}
+
 	req, err := http.NewRequest(method, u.String(), b)
 	if err != nil {
 		return err
@@ -110,25 +265,115 @@ func (c *Client) Request(ctx context.Context, method string, path endpoint, body
 
 	req = req.WithContext(ctx)
 
-	res, err := c.client.Do(req)
+	res, err := c.send(req, path)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
-	// TODO: add tests for error on reading body
-	r, _ := ioutil.ReadAll(res.Body)
+	r := &bodyReader{r: res.Body, n: c.maxResponseSize}
+
+	if o, ok := output.(locatedOutput); ok {
+		o.setLocation(c.location)
+	}
 
 	// TODO: Implements the XML decoding based on the
 	// endpoint's ContextType(ctx) value.
 	// For now the JSON decoding will work.
-	if err := json.Unmarshal(r, output); err != nil {
-		return &ApiError{
+	if err := json.NewDecoder(r).Decode(output); err != nil {
+		e := &ApiError{
 			statusCode: res.StatusCode,
-			body:       r,
 			msg:        err.Error(),
+			err:        err,
+		}
+
+		switch {
+		case r.exceeded:
+			e.msg = fmt.Sprintf("response body exceeds %d bytes", c.maxResponseSize)
+			e.err = ErrResponseTooLarge
+		case r.err != nil:
+			e.msg = fmt.Sprintf("error reading response body: %s", r.err)
+			e.err = r.err
+		default:
+			r.fill()
 		}
+		e.body = r.head
+
+		return e
 	}
 
 	return nil
 }
+
+// SetMaxResponseSize sets the maximum size of a response body.
+// Larger responses fail with an ApiError wrapping ErrResponseTooLarge.
+// A non-positive n restores DefaultMaxResponseSize.
+func (c *Client) SetMaxResponseSize(n int64) {
+	if n <= 0 {
+		n = DefaultMaxResponseSize
+	}
+	c.maxResponseSize = n
+}
+
+// SetLocation sets the time zone of the times sent by the API without
+// zone, America/Sao_Paulo by default, e.g. of the reports and scheduled
+// rides. The times sent with zone are converted to it, so all times of
+// the reports, and of their CSV, are in it.
+//
+// SetLocation must be called before the client is used.
+func (c *Client) SetLocation(loc *time.Location) {
+	c.location = loc
+}
+
+// bodyReader reads up to n bytes from r, keeping the
+// first bytes read and the error that stopped the reading.
+type bodyReader struct {
+	r        io.Reader
+	n        int64
+	head     []byte
+	exceeded bool
+	err      error
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	if b.n <= 0 {
+		// Tells a body of exactly the limit from a larger one.
+		var one [1]byte
+		if n, _ := io.ReadFull(b.r, one[:]); n > 0 {
+			b.exceeded = true
+			return 0, ErrResponseTooLarge
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > b.n {
+		p = p[:b.n]
+	}
+
+	n, err := b.r.Read(p)
+	b.n -= int64(n)
+
+	if rest := errBodySize - len(b.head); rest > 0 {
+		if rest > n {
+			rest = n
+		}
+		b.head = append(b.head, p[:rest]...)
+	}
+
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+
+	return n, err
+}
+
+// fill reads the body until the diagnostic bytes
+// are kept or the reading stops.
+func (b *bodyReader) fill() {
+	var p [512]byte
+	for len(b.head) < errBodySize && !b.exceeded && b.err == nil {
+		if _, err := b.Read(p[:]); err != nil {
+			return
+		}
+	}
+}
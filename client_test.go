@@ -3,7 +3,10 @@ package liguetaxi
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -21,7 +24,7 @@ func TestApiError(t *testing.T) {
 		msg    = http.StatusText(http.StatusBadRequest)
 	)
 
-	e := &ApiError{status, body, msg}
+	e := &ApiError{statusCode: status, body: body, msg: msg}
 
 	if want := fmt.Sprintf(errFmt, msg, status, body); e.Error() != want {
 		t.Errorf("got message from Error.Error(): %s; want %s.", e.Error(), want)
@@ -50,7 +53,7 @@ func TestNewClient(t *testing.T) {
 			t.Errorf("got c.host : %s; want %s.", c.host, tc.host)
 		}
 
-		tr := http.RoundTripper(&Transport{tc.token, http.DefaultClient.Transport})
+		tr := http.RoundTripper(&Transport{Token: tc.token, Base: http.DefaultClient.Transport})
 		if tc.client != nil {
 			tr = tc.client.Transport
 		}
@@ -61,6 +64,16 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestNewClientWithTokenSource(t *testing.T) {
+	src := StaticToken("abc")
+	c := NewClientWithTokenSource(&url.URL{}, src, nil)
+
+	want := &Transport{Base: http.DefaultClient.Transport, Source: src}
+	if !reflect.DeepEqual(c.client.Transport, want) {
+		t.Errorf("got Transport %+v; want %+v.", c.client.Transport, want)
+	}
+}
+
 func TestNewClientServices(t *testing.T) {
 	c := NewClient(&url.URL{}, "", nil)
 
@@ -294,3 +307,139 @@ func TestClientRequestWithContext(t *testing.T) {
 		t.Errorf("got error nil; want not nil")
 	}
 }
+
+func TestClientRequestBodyErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		max      int64
+		handler  func(w http.ResponseWriter, r *http.Request)
+		wantErr  error
+		wantBody []byte
+	}{
+		{
+			"too large",
+			10,
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"name":"Testing a large name"}`))
+			},
+			ErrResponseTooLarge,
+			[]byte(`{"name":"T`),
+		},
+		{
+			"read error",
+			0,
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "100")
+				w.Write([]byte(`{"name":`))
+			},
+			io.ErrUnexpectedEOF,
+			[]byte(`{"name":`),
+		},
+		{
+			"diagnostics truncated",
+			0,
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write(bytes.Repeat([]byte("x"), 2*errBodySize))
+			},
+			nil,
+			bytes.Repeat([]byte("x"), errBodySize),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // creates scoped test case
+		t.Run(tc.name, func(t *testing.T) {
+			s := newMockServer(tc.handler)
+			defer s.Close()
+
+			u, _ := url.Parse(s.URL)
+			c := NewClient(u, "abc", nil)
+			c.SetMaxResponseSize(tc.max)
+
+			err := c.Request(context.Background(), http.MethodPost, "foo", nil, &dummy{})
+
+			e, ok := err.(*ApiError)
+			if !ok {
+				t.Fatalf("got error %T: %v; want *ApiError.", err, err)
+			}
+
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("got error: %v; want it to wrap %v.", err, tc.wantErr)
+			}
+
+			if !bytes.Equal(e.body, tc.wantBody) {
+				t.Errorf("got Error.body: %s; want %s.", e.body, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestClientRequestMaxResponseSize(t *testing.T) {
+	s := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"Testing"}`))
+	})
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := NewClient(u, "abc", nil)
+
+	if c.maxResponseSize != DefaultMaxResponseSize {
+		t.Errorf("got max response size %d; want %d.", c.maxResponseSize, DefaultMaxResponseSize)
+	}
+
+	// A body of exactly the maximum size is accepted.
+	c.SetMaxResponseSize(int64(len(`{"name":"Testing"}`)))
+
+	var out dummy
+	if err := c.Request(context.Background(), http.MethodPost, "foo", nil, &out); err != nil {
+		t.Fatalf("got error calling Client.Request(): %s; want nil.", err.Error())
+	}
+
+	if out.Name != "Testing" {
+		t.Errorf("got output %+v; want %+v.", out, dummy{"Testing"})
+	}
+}
+
+func TestReqStatus(t *testing.T) {
+	testCases := []struct {
+		b        []byte
+		want     ReqStatus
+		wantStr  string
+		wantJSON []byte
+	}{
+		{[]byte(`1`), ReqStatusOK, "OK", []byte(`1`)},
+		{[]byte(`"1"`), ReqStatusOK, "OK", []byte(`1`)},
+		{[]byte(`0`), ReqStatusFail, "Fail", []byte(`0`)},
+		{[]byte(`2`), ReqStatus(2), "2", []byte(`2`)},
+	}
+
+	for _, tc := range testCases {
+		var s ReqStatus
+		if err := json.Unmarshal(tc.b, &s); err != nil {
+			t.Fatalf("got error unmarshaling ReqStatus %s: %s; want nil.", tc.b, err.Error())
+		}
+
+		if s != tc.want {
+			t.Errorf("got ReqStatus %d from %s; want %d.", s, tc.b, tc.want)
+		}
+
+		if str := s.String(); str != tc.wantStr {
+			t.Errorf("got ReqStatus.String(): %s; want %s.", str, tc.wantStr)
+		}
+
+		if b, _ := json.Marshal(s); !bytes.Equal(b, tc.wantJSON) {
+			t.Errorf("got ReqStatus marshaled: %s; want %s.", b, tc.wantJSON)
+		}
+
+		text, _ := s.MarshalText()
+		var back ReqStatus
+		if err := back.UnmarshalText(text); err != nil || back != s {
+			t.Errorf("got ReqStatus %d, %v from text %s; want %d, nil.", back, err, text, s)
+		}
+	}
+
+	var s ReqStatus
+	if err := json.Unmarshal([]byte(`"OK"`), &s); err == nil {
+		t.Error("got error nil unmarshaling invalid ReqStatus; want not nil.")
+	}
+}
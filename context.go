@@ -0,0 +1,96 @@
+package liguetaxi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Context keys for the per-request overrides.
+type (
+	tokenKey   struct{}
+	hostKey    struct{}
+	timeoutKey struct{}
+	headerKey  struct{}
+)
+
+// WithResType returns a copy of ctx setting the type
+// of the response payload (Json or Xml) of the requests.
+func WithResType(ctx context.Context, t string) context.Context {
+	return context.WithValue(ctx, ResType, t)
+}
+
+// WithToken returns a copy of ctx overriding the token
+// sent by the Transport on the requests made with it.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// WithHost returns a copy of ctx overriding the Client's
+// host on the requests made with it.
+func WithHost(ctx context.Context, host *url.URL) context.Context {
+	return context.WithValue(ctx, hostKey{}, host)
+}
+
+// WithTimeout returns a copy of ctx limiting each request
+// made with it to the duration d. Unlike context.WithTimeout,
+// the time starts counting when the request is made.
+func WithTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, d)
+}
+
+// WithHeader returns a copy of ctx adding the header to the
+// requests made with it. The Authorization header can not be
+// overridden, use WithToken instead.
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	h := contextHeader(ctx).Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	h.Add(key, value)
+
+	return context.WithValue(ctx, headerKey{}, h)
+}
+
+// contextToken returns the token override of ctx, if any.
+func contextToken(ctx context.Context) (string, bool) {
+	t, ok := ctx.Value(tokenKey{}).(string)
+	return t, ok && t != ""
+}
+
+// contextHost returns the host override of ctx, if any.
+func contextHost(ctx context.Context) (*url.URL, bool) {
+	h, ok := ctx.Value(hostKey{}).(*url.URL)
+	return h, ok && h != nil
+}
+
+// contextTimeout returns the timeout override of ctx, if any.
+func contextTimeout(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(timeoutKey{}).(time.Duration)
+	return d, ok && d > 0
+}
+
+// contextHeader returns the headers added to ctx, if any.
+func contextHeader(ctx context.Context) http.Header {
+	h, _ := ctx.Value(headerKey{}).(http.Header)
+	return h
+}
+
+// contextScope returns a key identifying the account and
+// host the requests made with ctx are sent to, or an
+// empty string for the Client's defaults. The token is
+// hashed, as the keys may be stored by external caches.
+func contextScope(ctx context.Context) string {
+	var scope string
+	if h, ok := contextHost(ctx); ok {
+		scope = h.String()
+	}
+	if t, ok := contextToken(ctx); ok {
+		sum := sha256.Sum256([]byte(t))
+		scope += "|" + hex.EncodeToString(sum[:])
+	}
+	return scope
+}
@@ -0,0 +1,127 @@
+package liguetaxi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContextOverrides(t *testing.T) {
+	host, _ := url.Parse("https://staging.test")
+
+	ctx := context.Background()
+	ctx = WithResType(ctx, Xml)
+	ctx = WithToken(ctx, "abc")
+	ctx = WithHost(ctx, host)
+	ctx = WithTimeout(ctx, time.Second)
+	ctx = WithHeader(ctx, "X-Test", "1")
+	child := WithHeader(ctx, "X-Test", "2")
+
+	if got := endpoint("test").ContextType(ctx); got != Xml {
+		t.Errorf("got ContextType(): %s; want %s.", got, Xml)
+	}
+
+	if got, ok := contextToken(ctx); !ok || got != "abc" {
+		t.Errorf("got contextToken(): %s, %t; want abc, true.", got, ok)
+	}
+
+	if got, ok := contextHost(ctx); !ok || got != host {
+		t.Errorf("got contextHost(): %s, %t; want %s, true.", got, ok, host)
+	}
+
+	if got, ok := contextTimeout(ctx); !ok || got != time.Second {
+		t.Errorf("got contextTimeout(): %s, %t; want %s, true.", got, ok, time.Second)
+	}
+
+	if got, want := contextHeader(ctx), (http.Header{"X-Test": {"1"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got contextHeader(): %v; want %v.", got, want)
+	}
+
+	if got, want := contextHeader(child), (http.Header{"X-Test": {"1", "2"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got contextHeader() of child: %v; want %v.", got, want)
+	}
+
+	// The token is not kept in the scope, but its SHA-256 hash.
+	want := "https://staging.test|ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := contextScope(ctx); got != want {
+		t.Errorf("got contextScope(): %s; want %s.", got, want)
+	}
+}
+
+func TestContextOverridesEmpty(t *testing.T) {
+	ctx := context.Background()
+
+	if _, ok := contextToken(WithToken(ctx, "")); ok {
+		t.Error("got empty token override; want none.")
+	}
+
+	if _, ok := contextHost(WithHost(ctx, nil)); ok {
+		t.Error("got nil host override; want none.")
+	}
+
+	if _, ok := contextTimeout(WithTimeout(ctx, 0)); ok {
+		t.Error("got zero timeout override; want none.")
+	}
+
+	if h := contextHeader(ctx); h != nil {
+		t.Errorf("got contextHeader(): %v; want nil.", h)
+	}
+
+	if s := contextScope(ctx); s != "" {
+		t.Errorf("got contextScope(): %s; want empty.", s)
+	}
+}
+
+func TestClientRequestContextOverrides(t *testing.T) {
+	s := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Basic def" {
+			t.Errorf("got Authorization Header: %s; want Basic def.", auth)
+		}
+
+		if h := r.Header.Get("X-Test"); h != "1" {
+			t.Errorf("got X-Test Header: %s; want 1.", h)
+		}
+
+		if want := "/api/foo/xml"; r.URL.Path != want {
+			t.Errorf("got Request.URL: %s; want %s.", r.URL.Path, want)
+		}
+		w.Write([]byte(`{}`))
+	})
+	defer s.Close()
+
+	// The client host is never reached.
+	c := NewClient(&url.URL{Scheme: "http", Host: "invalid.test"}, "abc", nil)
+
+	u, _ := url.Parse(s.URL)
+	ctx := WithHost(context.Background(), u)
+	ctx = WithToken(ctx, "def")
+	ctx = WithHeader(ctx, "X-Test", "1")
+	ctx = WithResType(ctx, Xml)
+
+	if err := c.Request(ctx, http.MethodPost, "foo", nil, &dummy{}); err != nil {
+		t.Fatalf("got error calling Client.Request(): %s; want nil.", err.Error())
+	}
+}
+
+func TestClientRequestContextTimeout(t *testing.T) {
+	release := make(chan struct{})
+	s := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer s.Close()
+	defer close(release)
+
+	u, _ := url.Parse(s.URL)
+	c := NewClient(u, "abc", nil)
+
+	ctx := WithTimeout(context.Background(), 10*time.Millisecond)
+	if err := c.Request(ctx, http.MethodPost, "foo", nil, &dummy{}); err == nil {
+		t.Error("got error nil; want not nil.")
+	}
+}
@@ -0,0 +1,239 @@
+package liguetaxi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The Flex types tolerate the quirks of the API responses: empty
+// values sent as `{}` or `[]`, numbers and booleans sent as strings
+// and vice versa.
+
+// isEmptyJSON reports whether b is null or an empty object, array or string.
+func isEmptyJSON(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	switch string(b) {
+	case ``, `null`, `{}`, `[]`, `""`:
+		return true
+	}
+
+	if b[0] != '{' && b[0] != '[' {
+		return false
+	}
+
+	// Empty objects and arrays may contain white space.
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return false
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// unquote returns the content of b if it is a JSON
+// string, or b itself for other JSON values.
+func unquote(b []byte) (string, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || b[0] != '"' {
+		return string(b), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// FlexString is a string also decoded from numbers, booleans
+// and empty objects or arrays, the latter as an empty string.
+type FlexString string
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// FlexString type.
+func (f *FlexString) UnmarshalJSON(b []byte) error {
+	if isEmptyJSON(b) {
+		*f = ""
+		return nil
+	}
+
+	b = bytes.TrimSpace(b)
+	switch b[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*f = FlexString(s)
+	case '{', '[':
+		return fmt.Errorf("liguetaxi: cannot decode %s into FlexString", b)
+	default:
+		// Numbers and booleans are kept as sent.
+		*f = FlexString(b)
+	}
+	return nil
+}
+
+// String returns underlying string for
+// FlexString type.
+func (f FlexString) String() string {
+	return string(f)
+}
+
+// FlexInt is an integer also decoded from strings holding numbers
+// and from empty strings, objects or arrays, the latter as zero.
+type FlexInt int64
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// FlexInt type.
+func (f *FlexInt) UnmarshalJSON(b []byte) error {
+	if isEmptyJSON(b) {
+		*f = 0
+		return nil
+	}
+
+	s, err := unquote(b)
+	if err != nil {
+		return err
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*f = FlexInt(i)
+		return nil
+	}
+
+	// Integers may be sent as floats, e.g. 10.0.
+	fl, err := strconv.ParseFloat(s, 64)
+	if err != nil || fl != float64(int64(fl)) {
+		return fmt.Errorf("liguetaxi: cannot decode %s into FlexInt", b)
+	}
+	*f = FlexInt(fl)
+	return nil
+}
+
+// Int returns underlying int64 for
+// FlexInt type.
+func (f FlexInt) Int() int64 {
+	return int64(f)
+}
+
+// FlexFloat is a float also decoded from strings holding numbers
+// and from empty strings, objects or arrays, the latter as zero.
+type FlexFloat float64
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// FlexFloat type.
+func (f *FlexFloat) UnmarshalJSON(b []byte) error {
+	if isEmptyJSON(b) {
+		*f = 0
+		return nil
+	}
+
+	s, err := unquote(b)
+	if err != nil {
+		return err
+	}
+
+	fl, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("liguetaxi: cannot decode %s into FlexFloat", b)
+	}
+	*f = FlexFloat(fl)
+	return nil
+}
+
+// Float returns underlying float64 for
+// FlexFloat type.
+func (f FlexFloat) Float() float64 {
+	return float64(f)
+}
+
+// FlexBool is a boolean also decoded from numbers and strings,
+// such as 1, "0", "true" or "S" and "N" (sim and não), and from
+// empty strings, objects or arrays, the latter as false.
+type FlexBool bool
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// FlexBool type.
+func (f *FlexBool) UnmarshalJSON(b []byte) error {
+	if isEmptyJSON(b) {
+		*f = false
+		return nil
+	}
+
+	s, err := unquote(b)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(s) {
+	case "s", "sim", "y", "yes":
+		*f = true
+		return nil
+	case "n", "nao", "não", "no":
+		*f = false
+		return nil
+	}
+
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("liguetaxi: cannot decode %s into FlexBool", b)
+	}
+	*f = FlexBool(v)
+	return nil
+}
+
+// Bool returns underlying bool for
+// FlexBool type.
+func (f FlexBool) Bool() bool {
+	return bool(f)
+}
+
+// FlexList is a list of raw JSON values also decoded from a single
+// value, as a list of one, and from empty strings or objects, as an
+// empty list. Decode unmarshals its values into a typed slice.
+type FlexList []json.RawMessage
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// FlexList type.
+func (f *FlexList) UnmarshalJSON(b []byte) error {
+	if isEmptyJSON(b) {
+		*f = nil
+		return nil
+	}
+
+	b = bytes.TrimSpace(b)
+	if b[0] != '[' {
+		*f = FlexList{append(json.RawMessage(nil), b...)}
+		return nil
+	}
+
+	var l []json.RawMessage
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*f = FlexList(l)
+	return nil
+}
+
+// Decode unmarshals the values of the list into
+// the slice pointed by v.
+func (f FlexList) Decode(v interface{}) error {
+	if f == nil {
+		f = FlexList{}
+	}
+
+	b, err := json.Marshal([]json.RawMessage(f))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,180 @@
+package liguetaxi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFlexStringUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b       string
+		want    FlexString
+		wantErr bool
+	}{
+		{`"text"`, "text", false},
+		{`""`, "", false},
+		{`{}`, "", false},
+		{`{ }`, "", false},
+		{`[]`, "", false},
+		{`null`, "", false},
+		{`123`, "123", false},
+		{`1.5`, "1.5", false},
+		{`true`, "true", false},
+		{`{"a":1}`, "", true},
+		{`[1]`, "", true},
+	}
+
+	for _, tc := range testCases {
+		var f FlexString
+		err := json.Unmarshal([]byte(tc.b), &f)
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("got error unmarshaling FlexString %s: %v; want error %t.", tc.b, err, tc.wantErr)
+		}
+
+		if f != tc.want {
+			t.Errorf("got FlexString %q from %s; want %q.", f, tc.b, tc.want)
+		}
+	}
+}
+
+func TestFlexIntUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b       string
+		want    FlexInt
+		wantErr bool
+	}{
+		{`10`, 10, false},
+		{`"10"`, 10, false},
+		{`" 10 "`, 10, false},
+		{`10.0`, 10, false},
+		{`"-3"`, -3, false},
+		{`""`, 0, false},
+		{`{}`, 0, false},
+		{`[]`, 0, false},
+		{`null`, 0, false},
+		{`10.5`, 0, true},
+		{`"abc"`, 0, true},
+		{`true`, 0, true},
+	}
+
+	for _, tc := range testCases {
+		var f FlexInt
+		err := json.Unmarshal([]byte(tc.b), &f)
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("got error unmarshaling FlexInt %s: %v; want error %t.", tc.b, err, tc.wantErr)
+		}
+
+		if f.Int() != int64(tc.want) {
+			t.Errorf("got FlexInt %d from %s; want %d.", f, tc.b, tc.want)
+		}
+	}
+}
+
+func TestFlexFloatUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b       string
+		want    FlexFloat
+		wantErr bool
+	}{
+		{`45.9`, 45.9, false},
+		{`"45.90"`, 45.9, false},
+		{`" 12 "`, 12, false},
+		{`"-3.5"`, -3.5, false},
+		{`""`, 0, false},
+		{`{}`, 0, false},
+		{`null`, 0, false},
+		{`"45,90"`, 0, true},
+		{`true`, 0, true},
+	}
+
+	for _, tc := range testCases {
+		var f FlexFloat
+		err := json.Unmarshal([]byte(tc.b), &f)
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("got error unmarshaling FlexFloat %s: %v; want error %t.", tc.b, err, tc.wantErr)
+		}
+
+		if f.Float() != float64(tc.want) {
+			t.Errorf("got FlexFloat %v from %s; want %v.", f, tc.b, tc.want)
+		}
+	}
+}
+
+func TestFlexBoolUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b       string
+		want    bool
+		wantErr bool
+	}{
+		{`true`, true, false},
+		{`false`, false, false},
+		{`1`, true, false},
+		{`0`, false, false},
+		{`"1"`, true, false},
+		{`"true"`, true, false},
+		{`"S"`, true, false},
+		{`"N"`, false, false},
+		{`"sim"`, true, false},
+		{`"não"`, false, false},
+		{`""`, false, false},
+		{`{}`, false, false},
+		{`null`, false, false},
+		{`"maybe"`, false, true},
+		{`2`, false, true},
+	}
+
+	for _, tc := range testCases {
+		var f FlexBool
+		err := json.Unmarshal([]byte(tc.b), &f)
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("got error unmarshaling FlexBool %s: %v; want error %t.", tc.b, err, tc.wantErr)
+		}
+
+		if f.Bool() != tc.want {
+			t.Errorf("got FlexBool %t from %s; want %t.", f, tc.b, tc.want)
+		}
+	}
+}
+
+func TestFlexListUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b       string
+		want    []dummy
+		wantErr bool
+	}{
+		{`[{"name":"a"},{"name":"b"}]`, []dummy{{"a"}, {"b"}}, false},
+		{`{"name":"a"}`, []dummy{{"a"}}, false},
+		{`[]`, []dummy{}, false},
+		{`{}`, []dummy{}, false},
+		{`""`, []dummy{}, false},
+		{`null`, []dummy{}, false},
+		{`[1,`, nil, true},
+	}
+
+	for _, tc := range testCases {
+		var f FlexList
+		err := json.Unmarshal([]byte(tc.b), &f)
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("got error unmarshaling FlexList %s: %v; want error %t.", tc.b, err, tc.wantErr)
+		}
+
+		if tc.wantErr {
+			continue
+		}
+
+		var got []dummy
+		if err := f.Decode(&got); err != nil {
+			t.Fatalf("got error calling FlexList.Decode(): %s; want nil.", err.Error())
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("got FlexList decoded from %s: %+v; want %+v.", tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package liguetaxi
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Endpoints whose concurrent identical requests share
+// a single round trip to the API.
+var coalescedEndpoints = map[endpoint]bool{
+	readUserEndpoint:       true,
+	readClassifierEndpoint: true,
+	listUsersEndpoint:      true,
+
+	listScheduledRidesEndpoint: true,
+	estimateFareEndpoint:       true,
+	rideStatusEndpoint:         true,
+}
+
+// flightCall is an in-flight or completed request.
+type flightCall struct {
+	done   chan struct{}
+	output interface{}
+	err    error
+
+	// callers is the number of callers waiting for the call,
+	// which is canceled once all of them gave up.
+	callers int
+	cancel  context.CancelFunc
+}
+
+// flightGroup deduplicates concurrent calls with the same key.
+// The zero value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do calls fn with a new value of the type of output, unless a call with
+// the same key is already in flight, and copies the value to output once
+// the call is done. The call is not bound to ctx, but to the callers
+// sharing it: each caller gives up when its own ctx is done, and the call
+// is canceled when all of them did. The output of calls sharing a key
+// must have the same type.
+func (g *flightGroup) do(ctx context.Context, key string, output interface{}, fn func(ctx context.Context, output interface{}) error) error {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+
+	c, ok := g.calls[key]
+	if !ok {
+		cctx, cancel := context.WithCancel(detachedContext{ctx})
+		c = &flightCall{done: make(chan struct{}), output: newOutput(output), cancel: cancel}
+		g.calls[key] = c
+
+		go g.call(cctx, key, c, fn)
+	}
+	c.callers++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		g.mu.Lock()
+		if c.callers--; c.callers == 0 {
+			c.cancel()
+			g.forget(key, c)
+		}
+		g.mu.Unlock()
+
+		return ctx.Err()
+	}
+
+	if c.err != nil {
+		return c.err
+	}
+	copyOutput(output, c.output)
+	return nil
+}
+
+func (g *flightGroup) call(ctx context.Context, key string, c *flightCall, fn func(ctx context.Context, output interface{}) error) {
+	defer c.cancel()
+
+	c.err = fn(ctx, c.output)
+
+	g.mu.Lock()
+	g.forget(key, c)
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// forget removes the call, unless replaced by a new one.
+// g.mu must be held.
+func (g *flightGroup) forget(key string, c *flightCall) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
+
+// detachedContext carries the values of its parent,
+// but neither its deadline nor its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+// newOutput returns a pointer to a new value of the type pointed by output.
+func newOutput(output interface{}) interface{} {
+	t := reflect.TypeOf(output)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return output
+	}
+	return reflect.New(t.Elem()).Interface()
+}
+
+// copyOutput sets the value pointed by dst with a deep copy of the
+// value pointed by src, so callers do not share what they are given.
+func copyOutput(dst, src interface{}) {
+	d, s := reflect.ValueOf(dst), reflect.ValueOf(src)
+	if d.Kind() != reflect.Ptr || d.IsNil() || s.Kind() != reflect.Ptr || s.IsNil() {
+		return
+	}
+
+	if d.Elem().CanSet() && s.Elem().Type().AssignableTo(d.Elem().Type()) {
+		d.Elem().Set(deepCopy(s.Elem()))
+	}
+}
+
+// deepCopy returns a copy of v not sharing its pointers, slices and maps.
+// Unexported fields are copied as they are.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		if hasReferences(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				c.Index(i).Set(deepCopy(v.Index(i)))
+			}
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for it := v.MapRange(); it.Next(); {
+			c.SetMapIndex(it.Key(), deepCopy(it.Value()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < c.NumField(); i++ {
+			if f := c.Field(i); f.CanSet() && hasReferences(f.Type()) {
+				f.Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
+
+// hasReferences reports whether values of the type may share memory.
+func hasReferences(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Struct:
+		return true
+	}
+	return false
+}
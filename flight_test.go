@@ -0,0 +1,319 @@
+package liguetaxi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitCallers waits until exactly n callers share the call in flight.
+func waitCallers(t *testing.T, g *flightGroup, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		g.mu.Lock()
+		var callers int
+		for _, c := range g.calls {
+			callers = c.callers
+		}
+		ok := len(g.calls) == 1 && callers == n
+		g.mu.Unlock()
+
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d callers sharing the call; want %d.", callers, n)
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestFlightGroup(t *testing.T) {
+	var (
+		g       flightGroup
+		calls   int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+		outputs = make([]dummy, 5)
+	)
+
+	for i := range outputs {
+		wg.Add(1)
+		go func(out *dummy) {
+			defer wg.Done()
+			err := g.do(context.Background(), "key", out, func(ctx context.Context, output interface{}) error {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				output.(*dummy).Name = "Testing"
+				return nil
+			})
+			if err != nil {
+				t.Errorf("got error calling flightGroup.do(): %s; want nil.", err.Error())
+			}
+		}(&outputs[i])
+	}
+
+	waitCallers(t, &g, len(outputs))
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("got %d calls; want 1.", calls)
+	}
+
+	for i, out := range outputs {
+		if out.Name != "Testing" {
+			t.Errorf("got output[%d]: %+v; want %+v.", i, out, dummy{"Testing"})
+		}
+	}
+}
+
+func TestFlightGroupError(t *testing.T) {
+	var (
+		g       flightGroup
+		release = make(chan struct{})
+		err     = errors.New("Error")
+		errs    = make(chan error, 2)
+	)
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			errs <- g.do(context.Background(), "key", &dummy{}, func(ctx context.Context, output interface{}) error {
+				<-release
+				return err
+			})
+		}()
+	}
+
+	waitCallers(t, &g, 2)
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		if e := <-errs; e != err {
+			t.Errorf("got error: %v; want %v.", e, err)
+		}
+	}
+}
+
+func TestFlightGroupContext(t *testing.T) {
+	var (
+		g       flightGroup
+		release = make(chan struct{})
+		started = make(chan struct{})
+	)
+	defer close(release)
+
+	go g.do(context.Background(), "key", &dummy{}, func(ctx context.Context, output interface{}) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := g.do(ctx, "key", &dummy{}, func(ctx context.Context, output interface{}) error { return nil }); err != context.Canceled {
+		t.Errorf("got error: %v; want %v.", err, context.Canceled)
+	}
+}
+
+func TestFlightGroupLeaderCanceled(t *testing.T) {
+	var (
+		g       flightGroup
+		release = make(chan struct{})
+		started = make(chan struct{})
+		errs    = make(chan error, 1)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		errs <- g.do(ctx, "key", &dummy{}, func(ctx context.Context, output interface{}) error {
+			close(started)
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			output.(*dummy).Name = "Testing"
+			return nil
+		})
+	}()
+	<-started
+
+	out := &dummy{}
+	waiter := make(chan error, 1)
+	go func() {
+		waiter <- g.do(context.Background(), "key", out, nil)
+	}()
+
+	waitCallers(t, &g, 2)
+	cancel()
+	waitCallers(t, &g, 1)
+	close(release)
+
+	if err := <-waiter; err != nil {
+		t.Errorf("got error of the waiter: %v; want nil.", err)
+	}
+
+	if out.Name != "Testing" {
+		t.Errorf("got output %+v; want %+v.", out, dummy{"Testing"})
+	}
+
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("got error of the leader: %v; want %v.", err, context.Canceled)
+	}
+}
+
+func TestFlightGroupAllCanceled(t *testing.T) {
+	var (
+		g        flightGroup
+		canceled = make(chan struct{})
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	err := g.do(ctx, "key", &dummy{}, func(ctx context.Context, output interface{}) error {
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Errorf("got error: %v; want %v.", err, context.Canceled)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("got call running after all callers gave up; want canceled.")
+	}
+}
+
+func TestCopyOutput(t *testing.T) {
+	email := FlexString("test@example.com")
+	src := &UserResponse{Data: DataUser{Email: &email, Status: UserStatusActive.New()}}
+
+	dst := &UserResponse{}
+	copyOutput(dst, src)
+
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("got copy %+v; want %+v.", dst, src)
+	}
+
+	if dst.Data.Email == src.Data.Email || dst.Data.Status == src.Data.Status {
+		t.Error("got copy sharing the pointers of the source; want not shared.")
+	}
+}
+
+func TestClientRequestCoalescing(t *testing.T) {
+	var (
+		hits    int32
+		release = make(chan struct{})
+	)
+
+	s := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		w.Write([]byte(`{"status":1,"data":{"authorized_id":"1"}}`))
+	})
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := NewClient(u, "abc", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := c.User.Read(context.Background(), "123", "")
+			if err != nil {
+				t.Errorf("got error calling User.Read(): %s; want nil.", err.Error())
+				return
+			}
+			if res.Data.ID != "1" {
+				t.Errorf("got user ID: %s; want 1.", res.Data.ID)
+			}
+		}()
+	}
+	waitCallers(t, &c.flight, 5)
+	close(release)
+	wg.Wait()
+
+	if hits != 1 {
+		t.Errorf("got %d requests to the server; want 1.", hits)
+	}
+
+	// Callers may change what they are given.
+	atomic.StoreInt32(&hits, 0)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if res, err := c.User.Read(context.Background(), "123", ""); err == nil {
+				res.Data.Name = FlexString(strconv.Itoa(i))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Non-read endpoints are never coalesced.
+	atomic.StoreInt32(&hits, 0)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.User.Update(context.Background(), &User{ID: "123"})
+		}()
+	}
+	wg.Wait()
+
+	if hits != 3 {
+		t.Errorf("got %d update requests to the server; want 3.", hits)
+	}
+}
+
+func TestClientRequestCoalescingTimeout(t *testing.T) {
+	s := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"status":1,"data":{"authorized_id":"1"}}`))
+	})
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := NewClient(u, "abc", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	leader := make(chan error, 1)
+	go func() {
+		_, err := c.User.Read(ctx, "123", "")
+		leader <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	res, err := c.User.Read(context.Background(), "123", "")
+	if err != nil {
+		t.Fatalf("got error calling User.Read() after the leader timed out: %s; want nil.", err.Error())
+	}
+
+	if res.Data.ID != "1" {
+		t.Errorf("got user ID: %s; want 1.", res.Data.ID)
+	}
+
+	if err := <-leader; err != context.DeadlineExceeded {
+		t.Errorf("got error of the leader: %v; want %v.", err, context.DeadlineExceeded)
+	}
+}
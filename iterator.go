@@ -0,0 +1,213 @@
+package liguetaxi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// PageRequest identifies the page requested by a PageFunc.
+type PageRequest struct {
+	// Page is the number of the page, starting at 1.
+	Page int
+	// Offset is the number of values returned by the previous pages.
+	Offset int
+}
+
+// PageFunc returns the values of the page and whether more pages follow.
+// It suits both paged and offset endpoints, see PageRequest.
+type PageFunc func(ctx context.Context, p PageRequest) (values []interface{}, more bool, err error)
+
+// pageResult is the result of a page fetch.
+type pageResult struct {
+	req    PageRequest
+	values []interface{}
+	more   bool
+	err    error
+}
+
+// Iterator iterates over the values of a paginated endpoint. The next
+// page is prefetched while the values of the current one are consumed.
+// An Iterator is not safe for concurrent use.
+type Iterator struct {
+	fetch PageFunc
+
+	next    PageRequest
+	more    bool
+	values  []interface{}
+	cur     interface{}
+	pending chan pageResult
+	cancel  context.CancelFunc
+	err     error
+}
+
+// NewIterator returns an Iterator over the pages returned by fetch.
+func NewIterator(fetch PageFunc) *Iterator {
+	return &Iterator{fetch: fetch, next: PageRequest{Page: 1}, more: true}
+}
+
+// Next advances the iterator to the next value, waiting for the next page
+// if needed. It returns false when there are no more values, the context
+// is done or an error happened, the latter two returned by Err. The context
+// only bounds the wait of this call: the pages are fetched with its values,
+// but are only cancelled by Stop, so each call may have its own context.
+func (it *Iterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if err := ctx.Err(); err != nil {
+		it.fail(err)
+		return false
+	}
+
+	for len(it.values) == 0 {
+		if !it.more && it.pending == nil {
+			return false
+		}
+
+		if it.pending == nil {
+			it.prefetch(ctx)
+		}
+
+		var r pageResult
+		select {
+		case r = <-it.pending:
+		case <-ctx.Done():
+			it.fail(ctx.Err())
+			return false
+		}
+		it.cancel()
+		it.pending, it.cancel = nil, nil
+
+		if r.err != nil {
+			it.fail(r.err)
+			return false
+		}
+
+		it.values = r.values
+		it.next = PageRequest{Page: r.req.Page + 1, Offset: r.req.Offset + len(r.values)}
+		it.more = r.more && len(r.values) > 0
+		if it.more {
+			it.prefetch(ctx)
+		}
+	}
+
+	it.cur, it.values = it.values[0], it.values[1:]
+	return true
+}
+
+// prefetch fetches the next page in the background, with the values
+// of ctx, but cancelled by the iterator rather than by ctx.
+func (it *Iterator) prefetch(ctx context.Context) {
+	ctx, cancel := context.WithCancel(detachedContext{ctx})
+	// Buffered, so the fetch never blocks if the iterator is dropped.
+	ch := make(chan pageResult, 1)
+	req := it.next
+
+	go func() {
+		values, more, err := it.fetch(ctx, req)
+		ch <- pageResult{req, values, more, err}
+	}()
+
+	it.pending, it.cancel = ch, cancel
+}
+
+// fail stops the iterator with the error.
+func (it *Iterator) fail(err error) {
+	it.Stop()
+	it.err = err
+}
+
+// Value returns the current value.
+func (it *Iterator) Value() interface{} {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Stop ends the iteration, cancelling the prefetch of the next page.
+// It must be called when the iteration is abandoned before the end.
+func (it *Iterator) Stop() {
+	if it.cancel != nil {
+		it.cancel()
+	}
+	it.pending, it.cancel = nil, nil
+	it.more, it.values = false, nil
+}
+
+// listResponse is the response returned by the API when listing values,
+// a single value being decoded as a list of one.
+type listResponse struct {
+	Status     ReqStatus
+	Message    FlexString `json:"message"`
+	Data       FlexList   `json:"data"`
+	Page       FlexInt    `json:"page"`
+	TotalPages FlexInt    `json:"total_pages"`
+}
+
+// decode decodes the response b, and its data into the slice pointed by data.
+func (r *listResponse) decode(b []byte, data interface{}) error {
+	if err := json.Unmarshal(b, r); err != nil {
+		return err
+	}
+
+	if len(r.Data) == 0 {
+		return nil
+	}
+	return r.Data.Decode(data)
+}
+
+// each decodes the response b, calling fn with each of its values.
+func (r *listResponse) each(b []byte, fn func(v json.RawMessage) error) error {
+	if err := json.Unmarshal(b, r); err != nil {
+		return err
+	}
+
+	for _, v := range r.Data {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pageResponse is the response of a page of values.
+type pageResponse interface {
+	// page returns the status and message of the response,
+	// the slice of its values and the number of pages.
+	page() (status ReqStatus, message FlexString, values interface{}, totalPages int)
+}
+
+// listPages returns the PageFunc of the pages of what, requested by fetch.
+// As the API fails the requests of empty lists, failed pages without values
+// end the list, while other failed pages are errors.
+func listPages(what string, fetch func(ctx context.Context, page int) (pageResponse, error)) PageFunc {
+	return func(ctx context.Context, p PageRequest) ([]interface{}, bool, error) {
+		lp, err := fetch(ctx, p.Page)
+		if err != nil {
+			return nil, false, err
+		}
+
+		status, msg, data, total := lp.page()
+
+		v := reflect.ValueOf(data)
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = v.Index(i).Interface()
+		}
+
+		if status != ReqStatusOK {
+			if len(values) == 0 {
+				return nil, false, nil
+			}
+			return nil, false, fmt.Errorf("liguetaxi: listing %s page %d failed: %s", what, p.Page, msg)
+		}
+
+		return values, total == 0 || p.Page < total, nil
+	}
+}
@@ -0,0 +1,203 @@
+package liguetaxi
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// pagesFunc returns a PageFunc serving the pages, keeping the requests received.
+func pagesFunc(pages [][]interface{}, reqs chan<- PageRequest) PageFunc {
+	return func(ctx context.Context, p PageRequest) ([]interface{}, bool, error) {
+		if reqs != nil {
+			reqs <- p
+		}
+
+		if p.Page > len(pages) {
+			return nil, false, nil
+		}
+		return pages[p.Page-1], p.Page < len(pages), nil
+	}
+}
+
+func TestIterator(t *testing.T) {
+	pages := [][]interface{}{{1, 2}, {3}, {4, 5}}
+	reqs := make(chan PageRequest, len(pages))
+
+	it := NewIterator(pagesFunc(pages, reqs))
+
+	var got []interface{}
+	for it.Next(context.Background()) {
+		got = append(got, it.Value())
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("got error iterating: %s; want nil.", err.Error())
+	}
+
+	if want := []interface{}{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got values %v; want %v.", got, want)
+	}
+
+	close(reqs)
+	var gotReqs []PageRequest
+	for r := range reqs {
+		gotReqs = append(gotReqs, r)
+	}
+
+	if want := []PageRequest{{1, 0}, {2, 2}, {3, 3}}; !reflect.DeepEqual(gotReqs, want) {
+		t.Errorf("got page requests %v; want %v.", gotReqs, want)
+	}
+
+	if it.Next(context.Background()) {
+		t.Error("got Next() true after the last value; want false.")
+	}
+}
+
+func TestIteratorPrefetch(t *testing.T) {
+	pages := [][]interface{}{{1, 2}, {3}}
+	reqs := make(chan PageRequest, len(pages))
+
+	it := NewIterator(pagesFunc(pages, reqs))
+	defer it.Stop()
+
+	if !it.Next(context.Background()) {
+		t.Fatalf("got Next() false; want true.")
+	}
+
+	// The second page is requested while the first is consumed.
+	<-reqs
+	if r := <-reqs; r.Page != 2 {
+		t.Errorf("got prefetched page %d; want 2.", r.Page)
+	}
+}
+
+func TestIteratorCancel(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	it := NewIterator(func(ctx context.Context, p PageRequest) ([]interface{}, bool, error) {
+		if p.Page == 1 {
+			return []interface{}{1}, true, nil
+		}
+
+		// Blocks until cancelled.
+		select {
+		case <-ctx.Done():
+		case <-block:
+		}
+		return nil, false, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if !it.Next(ctx) {
+		t.Fatalf("got Next() false; want true.")
+	}
+
+	cancel()
+	if it.Next(ctx) {
+		t.Error("got Next() true after cancel; want false.")
+	}
+
+	if err := it.Err(); err != context.Canceled {
+		t.Errorf("got error: %v; want %v.", err, context.Canceled)
+	}
+}
+
+func TestIteratorCallContext(t *testing.T) {
+	it := NewIterator(func(ctx context.Context, p PageRequest) ([]interface{}, bool, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+		return []interface{}{p.Page}, p.Page < 3, nil
+	})
+	defer it.Stop()
+
+	var got []interface{}
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		ok := it.Next(ctx)
+		cancel()
+
+		if !ok {
+			break
+		}
+		got = append(got, it.Value())
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("got error iterating with a context per call: %s; want nil.", err.Error())
+	}
+
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got values %v; want %v.", got, want)
+	}
+}
+
+func TestIteratorStopCancel(t *testing.T) {
+	canceled := make(chan struct{})
+
+	it := NewIterator(func(ctx context.Context, p PageRequest) ([]interface{}, bool, error) {
+		if p.Page == 1 {
+			return []interface{}{1}, true, nil
+		}
+
+		<-ctx.Done()
+		close(canceled)
+		return nil, false, ctx.Err()
+	})
+
+	if !it.Next(context.Background()) {
+		t.Fatalf("got Next() false; want true.")
+	}
+	it.Stop()
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("got prefetch running after Stop(); want cancelled.")
+	}
+}
+
+func TestIteratorError(t *testing.T) {
+	err := errors.New("Error")
+
+	it := NewIterator(func(ctx context.Context, p PageRequest) ([]interface{}, bool, error) {
+		if p.Page == 1 {
+			return []interface{}{1}, true, nil
+		}
+		return nil, false, err
+	})
+
+	var n int
+	for it.Next(context.Background()) {
+		n++
+	}
+
+	if n != 1 {
+		t.Errorf("got %d values; want 1.", n)
+	}
+
+	if it.Err() != err {
+		t.Errorf("got error: %v; want %v.", it.Err(), err)
+	}
+}
+
+func TestIteratorStop(t *testing.T) {
+	it := NewIterator(pagesFunc([][]interface{}{{1, 2}, {3}}, nil))
+
+	if !it.Next(context.Background()) {
+		t.Fatalf("got Next() false; want true.")
+	}
+
+	it.Stop()
+	if it.Next(context.Background()) {
+		t.Error("got Next() true after Stop(); want false.")
+	}
+
+	if err := it.Err(); err != nil {
+		t.Errorf("got error: %v; want nil.", err)
+	}
+}
@@ -0,0 +1,54 @@
+package liguetaxi
+
+import "context"
+
+// Handler performs a request to an endpoint of the API, e.g.
+// "user/check_authorized", decoding the response into output.
+// The body and output are the typed values used by the services,
+// such as *User and *OperationResponse.
+type Handler func(ctx context.Context, method, endpoint string, body, output interface{}) error
+
+// Middleware wraps a Handler, running code before and after it.
+type Middleware func(next Handler) Handler
+
+// Use wraps the requests made by the client's services with the
+// middlewares, the first one being the outermost. The middlewares run
+// after those added by previous calls to Use, so Use(a); Use(b) is the
+// same as Use(a, b), but before the cache enabled by previous calls to
+// EnableCache.
+//
+// Use must be called before the client is used.
+func (c *Client) Use(mw ...Middleware) {
+	mc, ok := c.common.client.(*middlewareChain)
+	if !ok {
+		mc = &middlewareChain{next: c.common.client}
+		c.common.client = mc
+	}
+
+	mc.middlewares = append(mc.middlewares, mw...)
+	mc.build()
+}
+
+// middlewareChain is the requester that runs the
+// middlewares, in order, before the next requester.
+type middlewareChain struct {
+	next        requester
+	middlewares []Middleware
+	handler     Handler
+}
+
+// build chains the middlewares, the first one being the outermost.
+func (mc *middlewareChain) build() {
+	h := Handler(func(ctx context.Context, method, e string, body, output interface{}) error {
+		return mc.next.Request(ctx, method, endpoint(e), body, output)
+	})
+	for i := len(mc.middlewares) - 1; i >= 0; i-- {
+		h = mc.middlewares[i](h)
+	}
+	mc.handler = h
+}
+
+// Request implements the requester interface.
+func (mc *middlewareChain) Request(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+	return mc.handler(ctx, method, string(path), body, output)
+}
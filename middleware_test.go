@@ -0,0 +1,78 @@
+package liguetaxi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestClientUse(t *testing.T) {
+	var calls []string
+
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, method, endpoint string, body, output interface{}) error {
+				calls = append(calls, name+" before "+endpoint)
+				err := next(ctx, method, endpoint, body, output)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+
+	c := NewClient(&url.URL{}, "", nil)
+	// Replaces the client as the innermost requester.
+	c.common.client = requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		calls = append(calls, "request")
+		output.(*OperationResponse).Message = FlexString(body.(*User).Name)
+		return nil
+	})
+
+	c.Use(trace("a"), trace("b"))
+	c.Use(trace("c"))
+
+	op, err := c.User.Create(context.Background(), &User{Name: "Test"})
+	if err != nil {
+		t.Fatalf("got error calling User.Create(): %s; want nil.", err.Error())
+	}
+
+	if op.Message != "Test" {
+		t.Errorf("got message: %s; want Test.", op.Message)
+	}
+
+	// Successive calls add inner middlewares, as a single call does.
+	want := []string{
+		"a before user/create_authorized",
+		"b before user/create_authorized",
+		"c before user/create_authorized",
+		"request",
+		"c after",
+		"b after",
+		"a after",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls: %v; want %v.", calls, want)
+	}
+}
+
+func TestClientUseShortCircuit(t *testing.T) {
+	err := errors.New("Error")
+
+	c := NewClient(&url.URL{}, "", nil)
+	c.common.client = requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		t.Error("expected the middleware to stop the request.")
+		return nil
+	})
+
+	c.Use(func(next Handler) Handler {
+		return func(ctx context.Context, method, endpoint string, body, output interface{}) error {
+			return err
+		}
+	})
+
+	if _, e := c.User.Read(context.Background(), "123", ""); e != err {
+		t.Errorf("got error: %v; want %v.", e, err)
+	}
+}
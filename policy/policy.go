@@ -0,0 +1,477 @@
+// Package policy evaluates proposed rides against the ride policies of
+// a company, e.g. "cost center CC-100 may only ride from 7am to 10pm
+// under R$ 80", before the ride is requested.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mobilitee-smartmob/liguetaxi"
+	"gopkg.in/yaml.v2"
+)
+
+// Effect is the decision of a policy when no rule matches the subject.
+type Effect string
+
+// Effects of a policy.
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Number of classifier fields of the users.
+const classifierFields = 20
+
+// earthRadius is the mean radius of the Earth, in kilometers.
+const earthRadius = 6371.0
+
+// Subject is the user requesting the ride.
+type Subject struct {
+	Status liguetaxi.UserStatusCode
+	// Classifiers are the classifier values of the user,
+	// by field, e.g. "1" for Classifier1.
+	Classifiers map[string]string
+}
+
+// NewSubject returns the Subject with the status of the user d and the
+// classifier values of the user u, either of them may be nil.
+func NewSubject(d *liguetaxi.DataUser, u *liguetaxi.User) Subject {
+	var s Subject
+	if d != nil && d.Status != nil {
+		s.Status = *d.Status
+	}
+
+	if u == nil {
+		return s
+	}
+
+	for i := 1; i <= classifierFields; i++ {
+		f := strconv.Itoa(i)
+		if v := u.Classifier(f); v != "" {
+			if s.Classifiers == nil {
+				s.Classifiers = make(map[string]string)
+			}
+			s.Classifiers[f] = v
+		}
+	}
+	return s
+}
+
+// Ride is the proposed ride.
+type Ride struct {
+	// Time is the pickup time.
+	Time        time.Time
+	Origin      liguetaxi.Location
+	Destination liguetaxi.Location
+	Category    string
+	// Fare is the estimated fare, see liguetaxi.FareEstimate.
+	// If not positive, the fare is unknown and the ride is
+	// denied by the rules with a maximum fare.
+	Fare float64
+}
+
+// Match selects the subjects a rule applies to.
+// Empty fields match any subject.
+type Match struct {
+	// Statuses are the user status codes, e.g. "24" for active users.
+	// Subjects without status match "25", as inactive.
+	Statuses []liguetaxi.UserStatusCode `json:"statuses,omitempty"`
+	// Classifiers are the classifier values by field, e.g.
+	// {"1": ["CC-100"]}. All fields must match one of their values.
+	Classifiers map[string][]string `json:"classifiers,omitempty"`
+}
+
+func (m Match) matches(s Subject) bool {
+	if len(m.Statuses) > 0 {
+		status := s.Status
+		if status == "" {
+			// Users without status are inactive, see liguetaxi.UserStatusCode.
+			status = liguetaxi.UserStatusInactive
+		}
+
+		var ok bool
+		for _, st := range m.Statuses {
+			ok = ok || st == status
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	for f, values := range m.Classifiers {
+		if !contains(values, s.Classifiers[f], false) {
+			return false
+		}
+	}
+	return true
+}
+
+// TimeWindow is the time of the day rides are allowed, e.g. from "07:00"
+// to "22:00". Windows whose end is before the start cross midnight.
+type TimeWindow struct {
+	// Days are the weekdays of the window, e.g. "mon". If empty, any day.
+	// For windows crossing midnight, the day is the one of the start.
+	Days []string `json:"days,omitempty"`
+	From string   `json:"from"`
+	To   string   `json:"to"`
+
+	from, to int
+}
+
+// Weekdays by their names in the policies.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func (w *TimeWindow) parse() error {
+	for _, d := range w.Days {
+		if _, ok := weekdays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("policy: unknown weekday %q", d)
+		}
+	}
+
+	var err error
+	if w.from, err = parseClock(w.From); err != nil {
+		return err
+	}
+	if w.to, err = parseClock(w.To); err != nil {
+		return err
+	}
+
+	if w.from == w.to {
+		return fmt.Errorf("policy: empty time window from %s to %s", w.From, w.To)
+	}
+	return nil
+}
+
+// parseClock returns the minutes since midnight of the time HH:MM.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("policy: invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w TimeWindow) contains(t time.Time) bool {
+	min := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	if w.from > w.to && min < w.to {
+		// After midnight, in the window of the previous day.
+		day = (day + 6) % 7
+	} else if w.from <= w.to && (min < w.from || min >= w.to) {
+		return false
+	} else if w.from > w.to && min < w.from {
+		return false
+	}
+
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Place is an allowed origin or destination, matched either by part of
+// the address, case insensitive, or by the distance to its coordinates.
+type Place struct {
+	Address string `json:"address,omitempty"`
+
+	Lat float64 `json:"lat,omitempty"`
+	Lng float64 `json:"lng,omitempty"`
+	// RadiusKm is the maximum distance to the coordinates, in
+	// kilometers. It must be positive if the coordinates are set.
+	RadiusKm float64 `json:"radius_km,omitempty"`
+}
+
+// check returns an error if the coordinates of the place are set
+// without a radius, as they would never match.
+func (p Place) check() error {
+	if (p.Lat != 0 || p.Lng != 0) && p.RadiusKm <= 0 {
+		return fmt.Errorf("policy: place at %v,%v without a positive radius_km", p.Lat, p.Lng)
+	}
+	return nil
+}
+
+func (p Place) contains(l liguetaxi.Location) bool {
+	if p.Address != "" && strings.Contains(strings.ToLower(l.Address), strings.ToLower(p.Address)) {
+		return true
+	}
+	return p.RadiusKm > 0 && distance(p.Lat, p.Lng, l.Lat, l.Lng) <= p.RadiusKm
+}
+
+// distance returns the great-circle distance between the
+// coordinates, in kilometers, by the haversine formula.
+func distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := math.Pi / 180
+	dLat, dLng := (lat2-lat1)*rad, (lng2-lng1)*rad
+
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// Rule restricts the rides of the subjects it matches. Empty
+// restrictions allow any ride.
+type Rule struct {
+	Name  string `json:"name"`
+	Match Match  `json:"match"`
+
+	// Deny, if set, denies every ride of the subjects matched,
+	// e.g. of inactive users.
+	Deny bool `json:"deny,omitempty"`
+
+	// TimeWindows are the times of the day rides are allowed,
+	// in the time zone of the policy. Rides must be in one of them.
+	TimeWindows []TimeWindow `json:"time_windows,omitempty"`
+	// MaxFare is the maximum estimated fare, if positive.
+	MaxFare float64 `json:"max_fare,omitempty"`
+	// Origins and Destinations are the places allowed.
+	Origins      []Place `json:"origins,omitempty"`
+	Destinations []Place `json:"destinations,omitempty"`
+	// Categories are the ride categories allowed, e.g. "executivo".
+	Categories []string `json:"categories,omitempty"`
+}
+
+// check returns the reasons the ride breaks the rule.
+func (r *Rule) check(ride Ride, loc *time.Location) []string {
+	if r.Deny {
+		return []string{r.Name + ": rides not allowed"}
+	}
+
+	var reasons []string
+	if len(r.TimeWindows) > 0 {
+		t, ok := ride.Time.In(loc), false
+		for _, w := range r.TimeWindows {
+			ok = ok || w.contains(t)
+		}
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("%s: pickup at %s is outside the allowed times", r.Name, t.Format("Mon 15:04")))
+		}
+	}
+
+	switch {
+	case r.MaxFare <= 0:
+	case ride.Fare <= 0:
+		reasons = append(reasons, fmt.Sprintf("%s: fare is unknown, the maximum is %.2f", r.Name, r.MaxFare))
+	case ride.Fare > r.MaxFare:
+		reasons = append(reasons, fmt.Sprintf("%s: fare %.2f is over the maximum of %.2f", r.Name, ride.Fare, r.MaxFare))
+	}
+
+	if len(r.Origins) > 0 && !anyPlace(r.Origins, ride.Origin) {
+		reasons = append(reasons, fmt.Sprintf("%s: origin %q is not allowed", r.Name, ride.Origin.Address))
+	}
+
+	if len(r.Destinations) > 0 && !anyPlace(r.Destinations, ride.Destination) {
+		reasons = append(reasons, fmt.Sprintf("%s: destination %q is not allowed", r.Name, ride.Destination.Address))
+	}
+
+	if len(r.Categories) > 0 && !contains(r.Categories, ride.Category, true) {
+		reasons = append(reasons, fmt.Sprintf("%s: category %q is not allowed", r.Name, ride.Category))
+	}
+	return reasons
+}
+
+func anyPlace(places []Place, l liguetaxi.Location) bool {
+	for _, p := range places {
+		if p.contains(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, v string, fold bool) bool {
+	for _, s := range values {
+		if s == v || (fold && strings.EqualFold(s, v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decision is the result of the evaluation of a ride.
+type Decision struct {
+	Allowed bool
+	// Reasons are the reasons the ride was denied.
+	Reasons []string
+	// Rules are the names of the rules matching the subject.
+	Rules []string
+}
+
+// Policy is the set of rules of the rides. A ride is allowed if it
+// breaks none of the rules matching the subject, or, if no rule
+// matches, by the default effect.
+type Policy struct {
+	// Default is the effect when no rule matches. If empty, Allow.
+	Default Effect `json:"default,omitempty"`
+	// TimeZone is the IANA time zone of the time windows,
+	// e.g. "America/Sao_Paulo". If empty, UTC.
+	TimeZone string `json:"time_zone,omitempty"`
+	Rules    []Rule `json:"rules"`
+
+	loc *time.Location
+}
+
+// Load reads the policy as JSON from r, validating it.
+func Load(r io.Reader) (*Policy, error) {
+	p := &Policy{}
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, fmt.Errorf("policy: invalid JSON: %s", err.Error())
+	}
+
+	if err := p.Compile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadYAML reads the policy as YAML from r, validating it. The
+// fields are the same as in JSON, see Load.
+func LoadYAML(r io.Reader) (*Policy, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("policy: invalid YAML: %s", err.Error())
+	}
+
+	// Decoded through JSON, so the policy is read the same
+	// way as in Load, e.g. the user statuses as numbers.
+	j, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		return nil, fmt.Errorf("policy: invalid YAML: %s", err.Error())
+	}
+
+	p := &Policy{}
+	if err := json.Unmarshal(j, p); err != nil {
+		return nil, fmt.Errorf("policy: invalid YAML: %s", err.Error())
+	}
+
+	if err := p.Compile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// jsonValue returns the YAML value with the maps keyed
+// by strings, as YAML keys may be of any type.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonValue(e)
+		}
+	}
+	return v
+}
+
+// LoadFile reads the policy from the file at path, as YAML
+// for the .yaml and .yml extensions and as JSON otherwise.
+func LoadFile(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return LoadYAML(f)
+	}
+	return Load(f)
+}
+
+// Compile validates the policy built in code, preparing it for
+// Evaluate. Policies returned by Load are already compiled.
+func (p *Policy) Compile() error {
+	switch p.Default {
+	case "":
+		p.Default = Allow
+	case Allow, Deny:
+	default:
+		return fmt.Errorf("policy: unknown default effect %q", p.Default)
+	}
+
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return fmt.Errorf("policy: unknown time zone %q", p.TimeZone)
+	}
+	p.loc = loc
+
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			r.Name = "rule " + strconv.Itoa(i+1)
+		}
+
+		for j := range r.TimeWindows {
+			if err := r.TimeWindows[j].parse(); err != nil {
+				return fmt.Errorf("%s (%s)", err.Error(), r.Name)
+			}
+		}
+
+		for _, places := range [][]Place{r.Origins, r.Destinations} {
+			for _, pl := range places {
+				if err := pl.check(); err != nil {
+					return fmt.Errorf("%s (%s)", err.Error(), r.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate returns whether the subject may take the ride, with the
+// reasons if not. The policy must have been loaded or compiled.
+func (p *Policy) Evaluate(s Subject, ride Ride) Decision {
+	loc := p.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var d Decision
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if !r.Match.matches(s) {
+			continue
+		}
+
+		d.Rules = append(d.Rules, r.Name)
+		d.Reasons = append(d.Reasons, r.check(ride, loc)...)
+	}
+
+	if len(d.Rules) == 0 {
+		d.Allowed = p.Default != Deny
+		if !d.Allowed {
+			d.Reasons = []string{"no rule allows the rides of the user"}
+		}
+		return d
+	}
+
+	d.Allowed = len(d.Reasons) == 0
+	return d
+}
@@ -0,0 +1,245 @@
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mobilitee-smartmob/liguetaxi"
+)
+
+func TestNewSubject(t *testing.T) {
+	d := &liguetaxi.DataUser{Status: liguetaxi.UserStatusActive.New()}
+	u := &liguetaxi.User{Classifier1: "CC-100", Classifier20: "Sales"}
+
+	want := Subject{
+		Status:      liguetaxi.UserStatusActive,
+		Classifiers: map[string]string{"1": "CC-100", "20": "Sales"},
+	}
+	if got := NewSubject(d, u); !reflect.DeepEqual(got, want) {
+		t.Errorf("got NewSubject(): %+v; want %+v.", got, want)
+	}
+
+	if got := NewSubject(nil, nil); !reflect.DeepEqual(got, Subject{}) {
+		t.Errorf("got NewSubject(nil, nil): %+v; want zero Subject.", got)
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	day := func(d, h, m int) time.Time {
+		// 2026-03-01 is a Sunday.
+		return time.Date(2026, 3, 1+d, h, m, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		w    TimeWindow
+		t    time.Time
+		want bool
+	}{
+		{TimeWindow{From: "07:00", To: "22:00"}, day(1, 7, 0), true},
+		{TimeWindow{From: "07:00", To: "22:00"}, day(1, 21, 59), true},
+		{TimeWindow{From: "07:00", To: "22:00"}, day(1, 22, 0), false},
+		{TimeWindow{From: "07:00", To: "22:00"}, day(1, 6, 59), false},
+		{TimeWindow{Days: []string{"mon"}, From: "07:00", To: "22:00"}, day(1, 8, 0), true},
+		{TimeWindow{Days: []string{"mon"}, From: "07:00", To: "22:00"}, day(2, 8, 0), false},
+		{TimeWindow{Days: []string{"fri"}, From: "20:00", To: "02:00"}, day(5, 23, 0), true},
+		{TimeWindow{Days: []string{"fri"}, From: "20:00", To: "02:00"}, day(6, 1, 30), true},
+		{TimeWindow{Days: []string{"fri"}, From: "20:00", To: "02:00"}, day(5, 1, 30), false},
+		{TimeWindow{Days: []string{"fri"}, From: "20:00", To: "02:00"}, day(6, 3, 0), false},
+	}
+
+	for _, tc := range testCases {
+		if err := tc.w.parse(); err != nil {
+			t.Fatalf("got error parsing TimeWindow %+v: %s; want nil.", tc.w, err.Error())
+		}
+
+		if got := tc.w.contains(tc.t); got != tc.want {
+			t.Errorf("got TimeWindow{%v %s-%s}.contains(%s): %t; want %t.", tc.w.Days, tc.w.From, tc.w.To, tc.t.Format("Mon 15:04"), got, tc.want)
+		}
+	}
+}
+
+func TestPlaceContains(t *testing.T) {
+	gru := Place{Lat: -23.4356, Lng: -46.4731, RadiusKm: 2}
+
+	testCases := []struct {
+		p    Place
+		l    liguetaxi.Location
+		want bool
+	}{
+		{Place{Address: "av. paulista"}, liguetaxi.Location{Address: "Av. Paulista, 1000"}, true},
+		{Place{Address: "Av. Paulista"}, liguetaxi.Location{Address: "Rua Augusta, 10"}, false},
+		{gru, liguetaxi.Location{Lat: -23.4300, Lng: -46.4700}, true},
+		{gru, liguetaxi.Location{Lat: -23.5652, Lng: -46.6520}, false},
+		{Place{}, liguetaxi.Location{}, false},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.p.contains(tc.l); got != tc.want {
+			t.Errorf("got Place %+v contains %+v: %t; want %t.", tc.p, tc.l, got, tc.want)
+		}
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	p, err := LoadFile("testdata/policy.json")
+	if err != nil {
+		t.Fatalf("got error loading policy: %s; want nil.", err.Error())
+	}
+
+	sp, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skipf("time zone data not available: %s", err.Error())
+	}
+
+	var (
+		cc100 = Subject{liguetaxi.UserStatusActive, map[string]string{"1": "CC-100"}}
+		sales = Subject{liguetaxi.UserStatusActive, map[string]string{"1": "CC-200", "2": "Sales"}}
+		// 2026-03-06 is a Friday.
+		morning  = time.Date(2026, 3, 6, 9, 0, 0, 0, sp)
+		night    = time.Date(2026, 3, 6, 23, 30, 0, 0, sp)
+		paulista = liguetaxi.Location{Address: "Av. Paulista, 1000", Lat: -23.5652, Lng: -46.6520}
+		gru      = liguetaxi.Location{Address: "Aeroporto de Guarulhos", Lat: -23.4356, Lng: -46.4731}
+	)
+
+	testCases := []struct {
+		name        string
+		subject     Subject
+		ride        Ride
+		wantAllowed bool
+		wantReasons []string
+	}{
+		{"Allowed", cc100, Ride{Time: morning, Fare: 45.9}, true, nil},
+		{
+			"Unknown fare",
+			cc100,
+			Ride{Time: morning},
+			false,
+			[]string{"cost center CC-100: fare is unknown, the maximum is 80.00"},
+		},
+		{
+			"Late and expensive",
+			cc100,
+			// 01:00 UTC is 22:00 in São Paulo.
+			Ride{Time: time.Date(2026, 3, 7, 1, 0, 0, 0, time.UTC), Fare: 98.5},
+			false,
+			[]string{
+				"cost center CC-100: pickup at Fri 22:00 is outside the allowed times",
+				"cost center CC-100: fare 98.50 is over the maximum of 80.00",
+			},
+		},
+		{"Sales allowed", sales, Ride{Time: night, Origin: paulista, Destination: gru, Category: "Executivo"}, true, nil},
+		{
+			"Sales wrong places",
+			sales,
+			Ride{Time: night, Origin: gru, Destination: paulista, Category: "comum"},
+			false,
+			[]string{
+				`sales: origin "Aeroporto de Guarulhos" is not allowed`,
+				`sales: destination "Av. Paulista, 1000" is not allowed`,
+				`sales: category "comum" is not allowed`,
+			},
+		},
+		{
+			"Inactive",
+			Subject{liguetaxi.UserStatusInactive, map[string]string{"1": "CC-100"}},
+			Ride{Time: morning},
+			false,
+			[]string{"inactive users: rides not allowed"},
+		},
+		{
+			"No status",
+			Subject{Classifiers: map[string]string{"1": "CC-100"}},
+			Ride{Time: morning},
+			false,
+			[]string{"inactive users: rides not allowed"},
+		},
+		{
+			"No rule",
+			Subject{liguetaxi.UserStatusActive, map[string]string{"1": "CC-999"}},
+			Ride{Time: morning},
+			false,
+			[]string{"no rule allows the rides of the user"},
+		},
+	}
+
+	for _, tc := range testCases {
+		d := p.Evaluate(tc.subject, tc.ride)
+
+		if d.Allowed != tc.wantAllowed {
+			t.Errorf("got %s allowed: %t; want %t.", tc.name, d.Allowed, tc.wantAllowed)
+		}
+
+		if !reflect.DeepEqual(d.Reasons, tc.wantReasons) {
+			t.Errorf("got %s reasons: %q; want %q.", tc.name, d.Reasons, tc.wantReasons)
+		}
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	testCases := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"Invalid JSON", `{"rules":`, "policy: invalid JSON"},
+		{"Default", `{"default":"maybe"}`, `policy: unknown default effect "maybe"`},
+		{"Time zone", `{"time_zone":"Mars/Olympus"}`, `policy: unknown time zone "Mars/Olympus"`},
+		{"Time", `{"rules":[{"name":"a","time_windows":[{"from":"7am","to":"22:00"}]}]}`, `policy: invalid time "7am", want HH:MM (a)`},
+		{"Weekday", `{"rules":[{"time_windows":[{"days":["monday"],"from":"07:00","to":"22:00"}]}]}`, `policy: unknown weekday "monday" (rule 1)`},
+		{"Empty window", `{"rules":[{"time_windows":[{"from":"07:00","to":"07:00"}]}]}`, `policy: empty time window from 07:00 to 07:00 (rule 1)`},
+		{"Radius", `{"rules":[{"destinations":[{"lat":-23.4356,"lng":-46.4731}]}]}`, `policy: place at -23.4356,-46.4731 without a positive radius_km (rule 1)`},
+	}
+
+	for _, tc := range testCases {
+		_, err := Load(strings.NewReader(tc.json))
+		if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("got error loading %s: %v; want %s.", tc.name, err, tc.want)
+		}
+	}
+
+	yamlCases := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"Invalid YAML", "rules: [", "policy: invalid YAML"},
+		{"Wrong type", "rules: {name: a}", "policy: invalid YAML"},
+		{"Weekday", "rules: [{time_windows: [{days: [monday], from: '07:00', to: '22:00'}]}]", `policy: unknown weekday "monday" (rule 1)`},
+	}
+
+	for _, tc := range yamlCases {
+		_, err := LoadYAML(strings.NewReader(tc.yaml))
+		if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("got error loading %s: %v; want %s.", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestLoadFileYAML(t *testing.T) {
+	want, err := LoadFile("testdata/policy.json")
+	if err != nil {
+		t.Fatalf("got error loading JSON policy: %s; want nil.", err.Error())
+	}
+
+	got, err := LoadFile("testdata/policy.yaml")
+	if err != nil {
+		t.Fatalf("got error loading YAML policy: %s; want nil.", err.Error())
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got YAML policy %+v; want %+v.", got, want)
+	}
+}
+
+func TestPolicyDefault(t *testing.T) {
+	p := &Policy{}
+	if err := p.Compile(); err != nil {
+		t.Fatalf("got error compiling policy: %s; want nil.", err.Error())
+	}
+
+	if d := p.Evaluate(Subject{}, Ride{}); !d.Allowed || d.Reasons != nil {
+		t.Errorf("got decision %+v; want allowed without reasons.", d)
+	}
+}
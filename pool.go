@@ -0,0 +1,218 @@
+package liguetaxi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultPoolSize is the number of clients kept by
+// a ClientPool when none is specified.
+const DefaultPoolSize = 128
+
+// ErrNoTenant is returned by the ClientPool for requests
+// whose context carries no tenant.
+var ErrNoTenant = errors.New("liguetaxi: no tenant in context")
+
+// tenantKey is the context key for the tenant.
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant, which is
+// used by the ClientPool to route the requests made with it.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	t, ok := ctx.Value(tenantKey{}).(string)
+	return t, ok && t != ""
+}
+
+// CredentialsProvider provides the token of each tenant.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context, tenant string) (TokenSource, error)
+}
+
+// CredentialsFunc adapts a function to CredentialsProvider.
+type CredentialsFunc func(ctx context.Context, tenant string) (TokenSource, error)
+
+// Credentials implements the CredentialsProvider interface.
+func (f CredentialsFunc) Credentials(ctx context.Context, tenant string) (TokenSource, error) {
+	return f(ctx, tenant)
+}
+
+// PoolOptions configures the ClientPool.
+type PoolOptions struct {
+	// Size is the maximum number of clients kept. The least recently
+	// used client is evicted when full. If zero, DefaultPoolSize is used.
+	Size int
+
+	// IdleTimeout, if positive, evicts the clients
+	// not used for longer than it.
+	IdleTimeout time.Duration
+
+	// Transport is the RoundTripper shared by all clients. If nil,
+	// a clone of http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Setup, if not nil, is called for every client created,
+	// e.g. to enable its cache or circuit breaker.
+	Setup func(tenant string, c *Client)
+
+	// Location, if not nil, is set as the time zone of every
+	// client created, see Client.SetLocation.
+	Location *time.Location
+}
+
+// ClientPool lazily creates and keeps one Client per tenant, all of them
+// sharing the same underlying transport. The tenant of the requests made
+// through its services is taken from the context, see WithTenant.
+type ClientPool struct {
+	host     *url.URL
+	provider CredentialsProvider
+	opts     PoolOptions
+
+	mu      sync.Mutex
+	clients *LRUCache
+	// pending are the clients being created, by tenant.
+	pending map[string]*pendingClient
+
+	common service
+
+	// User is the service that handles http logic for requests
+	// related to the user, routed by the context's tenant.
+	User *UserService
+
+	// Ride is the service that handles http logic for requests
+	// related to the rides, routed by the context's tenant.
+	Ride *RideService
+
+	// Report is the service that handles http logic for requests
+	// related to the reports, routed by the context's tenant.
+	Report *ReportService
+}
+
+// NewClientPool returns a ClientPool for requests Ligue Taxi API
+// with the tokens given by the provider.
+func NewClientPool(host *url.URL, provider CredentialsProvider, opts *PoolOptions) *ClientPool {
+	p := &ClientPool{host: host, provider: provider}
+	if opts != nil {
+		p.opts = *opts
+	}
+
+	if p.opts.Transport == nil {
+		p.opts.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	p.clients = NewLRUCache(p.opts.Size)
+
+	p.common.client = p
+
+	p.User = (*UserService)(&p.common)
+	p.Ride = (*RideService)(&p.common)
+	p.Report = (*ReportService)(&p.common)
+	return p
+}
+
+// pendingClient is a client being created.
+type pendingClient struct {
+	done chan struct{}
+	c    *Client
+	err  error
+}
+
+// Client returns the client of the tenant, creating it if needed.
+// The credentials are requested once per tenant, without holding
+// up the clients of the other tenants.
+func (p *ClientPool) Client(ctx context.Context, tenant string) (*Client, error) {
+	p.mu.Lock()
+	if c, ok := p.clients.Get(tenant); ok {
+		// Renews the idle timeout.
+		p.clients.Set(tenant, c, p.opts.IdleTimeout)
+		p.mu.Unlock()
+		return c.(*Client), nil
+	}
+
+	if pc, ok := p.pending[tenant]; ok {
+		p.mu.Unlock()
+
+		select {
+		case <-pc.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if isContextErr(pc.err) && ctx.Err() == nil {
+			// The context of the caller creating it was done, not ours.
+			return p.Client(ctx, tenant)
+		}
+		return pc.c, pc.err
+	}
+
+	pc := &pendingClient{done: make(chan struct{})}
+	if p.pending == nil {
+		p.pending = make(map[string]*pendingClient)
+	}
+	p.pending[tenant] = pc
+	p.mu.Unlock()
+
+	pc.c, pc.err = p.newClient(ctx, tenant)
+
+	p.mu.Lock()
+	if pc.err == nil {
+		p.clients.Set(tenant, pc.c, p.opts.IdleTimeout)
+	}
+	delete(p.pending, tenant)
+	p.mu.Unlock()
+	close(pc.done)
+
+	return pc.c, pc.err
+}
+
+func (p *ClientPool) newClient(ctx context.Context, tenant string) (*Client, error) {
+	src, err := p.provider.Credentials(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewClientWithTokenSource(p.host, src, &http.Client{Transport: p.opts.Transport})
+	c.SetLocation(p.opts.Location)
+	if p.opts.Setup != nil {
+		p.opts.Setup(tenant, c)
+	}
+	return c, nil
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Evict removes the client of the tenant from the pool,
+// e.g. after its credentials changed.
+func (p *ClientPool) Evict(tenant string) {
+	p.clients.Delete(tenant)
+}
+
+// Len returns the number of clients in the pool.
+func (p *ClientPool) Len() int {
+	return p.clients.Len()
+}
+
+// Request implements the requester interface routing the
+// request to the client of the context's tenant.
+func (p *ClientPool) Request(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	c, err := p.Client(ctx, tenant)
+	if err != nil {
+		return err
+	}
+
+	return c.common.client.Request(ctx, method, path, body, output)
+}
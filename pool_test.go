@@ -0,0 +1,186 @@
+package liguetaxi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTenantFromContext(t *testing.T) {
+	testCases := []struct {
+		ctx    context.Context
+		want   string
+		wantOk bool
+	}{
+		{context.Background(), "", false},
+		{WithTenant(context.Background(), ""), "", false},
+		{WithTenant(context.Background(), "acme"), "acme", true},
+	}
+
+	for _, tc := range testCases {
+		got, ok := TenantFromContext(tc.ctx)
+		if got != tc.want || ok != tc.wantOk {
+			t.Errorf("got TenantFromContext(%+v): %s, %t; want %s, %t.", tc.ctx, got, ok, tc.want, tc.wantOk)
+		}
+	}
+}
+
+func TestClientPool(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		auths = make(map[string]int)
+	)
+
+	s := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths[r.Header.Get("Authorization")]++
+		mu.Unlock()
+		w.Write([]byte(`{"status":1}`))
+	})
+	defer s.Close()
+
+	var (
+		created []string
+		setup   []string
+	)
+
+	u, _ := url.Parse(s.URL)
+	p := NewClientPool(u, CredentialsFunc(func(ctx context.Context, tenant string) (TokenSource, error) {
+		created = append(created, tenant)
+		return StaticToken("token-" + tenant), nil
+	}), &PoolOptions{
+		Setup: func(tenant string, c *Client) { setup = append(setup, tenant) },
+	})
+
+	for _, tenant := range []string{"a", "b", "a"} {
+		res, err := p.User.Read(WithTenant(context.Background(), tenant), "123", "")
+		if err != nil {
+			t.Fatalf("got error calling User.Read() for tenant %s: %s; want nil.", tenant, err.Error())
+		}
+
+		if res.Status != ReqStatusOK {
+			t.Errorf("got status %d; want %d.", res.Status, ReqStatusOK)
+		}
+	}
+
+	if len(created) != 2 || len(setup) != 2 {
+		t.Errorf("got clients created for %v and set up for %v; want [a b].", created, setup)
+	}
+
+	if auths["Basic token-a"] != 2 || auths["Basic token-b"] != 1 {
+		t.Errorf("got Authorization Headers %v; want 2 for tenant a and 1 for tenant b.", auths)
+	}
+
+	a, _ := p.Client(context.Background(), "a")
+	b, _ := p.Client(context.Background(), "b")
+	if a.client.Transport.(*Transport).Base != b.client.Transport.(*Transport).Base {
+		t.Error("got different base transports; want the same.")
+	}
+}
+
+func TestClientPoolEviction(t *testing.T) {
+	var created int
+
+	p := NewClientPool(&url.URL{}, CredentialsFunc(func(ctx context.Context, tenant string) (TokenSource, error) {
+		created++
+		return StaticToken(tenant), nil
+	}), &PoolOptions{Size: 2, IdleTimeout: time.Millisecond})
+
+	ctx := context.Background()
+	p.Client(ctx, "a")
+	p.Client(ctx, "b")
+	p.Client(ctx, "c")
+
+	if l := p.Len(); l != 2 {
+		t.Errorf("got pool length %d; want 2.", l)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	p.Client(ctx, "c")
+
+	p.Evict("c")
+	p.Client(ctx, "c")
+
+	if created != 5 {
+		t.Errorf("got %d clients created; want 5.", created)
+	}
+}
+
+func TestClientPoolError(t *testing.T) {
+	err := errors.New("Error")
+
+	p := NewClientPool(&url.URL{}, CredentialsFunc(func(ctx context.Context, tenant string) (TokenSource, error) {
+		return nil, err
+	}), nil)
+
+	if _, e := p.User.Read(context.Background(), "123", ""); e != ErrNoTenant {
+		t.Errorf("got error: %v; want %v.", e, ErrNoTenant)
+	}
+
+	if _, e := p.User.Read(WithTenant(context.Background(), "a"), "123", ""); e != err {
+		t.Errorf("got error: %v; want %v.", e, err)
+	}
+
+	if l := p.Len(); l != 0 {
+		t.Errorf("got pool length %d; want 0.", l)
+	}
+}
+
+func TestClientPoolSlowTenant(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		started = make(chan struct{})
+		calls   = make(map[string]int)
+		mu      sync.Mutex
+	)
+
+	p := NewClientPool(&url.URL{}, CredentialsFunc(func(ctx context.Context, tenant string) (TokenSource, error) {
+		mu.Lock()
+		calls[tenant]++
+		first := calls[tenant] == 1
+		mu.Unlock()
+
+		if tenant == "slow" {
+			if first {
+				close(started)
+			}
+			<-release
+		}
+		return StaticToken("token"), nil
+	}), nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Client(context.Background(), "slow"); err != nil {
+				t.Errorf("got error creating the slow client: %s; want nil.", err.Error())
+			}
+		}()
+	}
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		p.Client(context.Background(), "fast")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("got the fast tenant blocked by the slow one; want not blocked.")
+	}
+
+	close(release)
+	wg.Wait()
+
+	if calls["slow"] != 1 {
+		t.Errorf("got %d credentials calls for the slow tenant; want 1.", calls["slow"])
+	}
+}
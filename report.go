@@ -0,0 +1,388 @@
+package liguetaxi
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReportPageSize is the number of rides requested
+// per page when none is specified.
+const DefaultReportPageSize = 100
+
+// Unverified endpoints, see the README.
+var (
+	// Endpoint for listing completed rides.
+	rideReportEndpoint endpoint = `report/rides`
+)
+
+// Layouts of the times sent by the API, by order of precedence.
+var reportTimeLayouts = []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02"}
+
+// Layout of the dates sent to the API.
+const reportDateLayout = "2006-01-02"
+
+// defaultLocation is the time zone of the times sent by the
+// API without zone, unless set by Client.SetLocation.
+var defaultLocation = loadLocation("America/Sao_Paulo", -3*60*60)
+
+// locatedOutput is implemented by the outputs holding
+// times sent by the API without zone.
+type locatedOutput interface {
+	// setLocation sets the time zone the times are decoded in.
+	setLocation(loc *time.Location)
+}
+
+// orDefaultLocation returns loc or, if nil, the default location.
+func orDefaultLocation(loc *time.Location) *time.Location {
+	if loc == nil {
+		return defaultLocation
+	}
+	return loc
+}
+
+// loadLocation returns the location with the name or, if the time zone
+// database is not available, a fixed zone with the offset in seconds.
+func loadLocation(name string, offset int) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone(name, offset)
+	}
+	return loc
+}
+
+// Driver is the driver of a ride.
+type Driver struct {
+	Name         string
+	Phone        string
+	Vehicle      string
+	LicensePlate string
+}
+
+// CompletedRide is the ride returned by the reports.
+type CompletedRide struct {
+	ID           string
+	AuthorizedID string
+	UserName     string
+	// Classifiers are the classifier values of the user when
+	// the ride was made, by field, e.g. "1" for classificador1.
+	Classifiers map[string]string
+	RequestedAt time.Time
+	CompletedAt time.Time
+	Origin      Location
+	Destination Location
+	Category    string
+	// Distance is the distance of the ride, in kilometers.
+	Distance float64
+	Duration time.Duration
+	Fare     float64
+	Currency string
+	Driver   Driver
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for CompletedRide
+// type, tolerating the quirks of the API. The times sent without zone
+// are taken as in America/Sao_Paulo, see Client.SetLocation.
+func (cr *CompletedRide) UnmarshalJSON(b []byte) error {
+	return cr.decode(b, nil)
+}
+
+// decode decodes the ride b, taking the times sent
+// without zone as in loc, or the default if nil.
+func (cr *CompletedRide) decode(b []byte, loc *time.Location) error {
+	loc = orDefaultLocation(loc)
+
+	var f struct {
+		ID           FlexString   `json:"ride_id"`
+		AuthorizedID FlexString   `json:"authorized_id"`
+		UserName     FlexString   `json:"client_name"`
+		RequestedAt  FlexString   `json:"requested_at"`
+		CompletedAt  FlexString   `json:"completed_at"`
+		Origin       flexLocation `json:"origin"`
+		Destination  flexLocation `json:"destination"`
+		Category     FlexString   `json:"category"`
+		Distance     FlexFloat    `json:"distance"`
+		Duration     FlexFloat    `json:"duration"`
+		Fare         FlexFloat    `json:"fare"`
+		Currency     FlexString   `json:"currency"`
+		Driver       struct {
+			Name         FlexString `json:"name"`
+			Phone        FlexString `json:"phone"`
+			Vehicle      FlexString `json:"vehicle"`
+			LicensePlate FlexString `json:"license_plate"`
+		} `json:"driver"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+
+	requested, err := parseReportTime(f.RequestedAt.String(), loc)
+	if err != nil {
+		return err
+	}
+
+	completed, err := parseReportTime(f.CompletedAt.String(), loc)
+	if err != nil {
+		return err
+	}
+
+	*cr = CompletedRide{
+		ID:           f.ID.String(),
+		AuthorizedID: f.AuthorizedID.String(),
+		UserName:     f.UserName.String(),
+		RequestedAt:  requested,
+		CompletedAt:  completed,
+		Origin:       f.Origin.location(),
+		Destination:  f.Destination.location(),
+		Category:     f.Category.String(),
+		Distance:     f.Distance.Float(),
+		Duration:     seconds(f.Duration),
+		Fare:         f.Fare.Float(),
+		Currency:     f.Currency.String(),
+		Driver: Driver{
+			f.Driver.Name.String(),
+			f.Driver.Phone.String(),
+			f.Driver.Vehicle.String(),
+			f.Driver.LicensePlate.String(),
+		},
+	}
+
+	// The classifier values are sent as classificador1 to classificador20.
+	var all map[string]json.RawMessage
+	json.Unmarshal(b, &all)
+	for k, v := range all {
+		if !strings.HasPrefix(k, "classificador") {
+			continue
+		}
+
+		var value FlexString
+		if json.Unmarshal(v, &value) != nil || value == "" {
+			continue
+		}
+
+		if cr.Classifiers == nil {
+			cr.Classifiers = make(map[string]string)
+		}
+		cr.Classifiers[strings.TrimPrefix(k, "classificador")] = value.String()
+	}
+	return nil
+}
+
+// parseReportTime parses the times sent by the API in loc. Times
+// without zone are parsed as in loc, empty ones as the zero time.
+func parseReportTime(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	for _, l := range reportTimeLayouts {
+		if t, err := time.ParseInLocation(l, s, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("liguetaxi: cannot parse time %q", s)
+}
+
+// RideReportFilter filters the completed rides listed by the reports.
+// Zero values are not filtered.
+type RideReportFilter struct {
+	// From and To are the first and last days of the rides.
+	From time.Time
+	To   time.Time
+
+	AuthorizedID string
+
+	// ClassifierField and ClassifierValue filter the rides by
+	// the classifier value of the user, e.g. "1" for classificador1.
+	ClassifierField string
+	ClassifierValue string
+
+	// PageSize is the number of rides requested per page.
+	// If zero, DefaultReportPageSize is used.
+	PageSize int
+}
+
+// rideReportQuery is sent to server when listing completed rides.
+type rideReportQuery struct {
+	From            string `json:"start_date,omitempty"`
+	To              string `json:"end_date,omitempty"`
+	AuthorizedID    string `json:"authorized_id,omitempty"`
+	ClassifierField string `json:"field,omitempty"`
+	ClassifierValue string `json:"field_value,omitempty"`
+	Page            int    `json:"page"`
+	PageSize        int    `json:"page_size"`
+}
+
+func (f *RideReportFilter) query(page int) rideReportQuery {
+	q := rideReportQuery{Page: page, PageSize: DefaultReportPageSize}
+	if f == nil {
+		return q
+	}
+
+	if !f.From.IsZero() {
+		q.From = f.From.Format(reportDateLayout)
+	}
+	if !f.To.IsZero() {
+		q.To = f.To.Format(reportDateLayout)
+	}
+	if f.PageSize > 0 {
+		q.PageSize = f.PageSize
+	}
+
+	q.AuthorizedID = f.AuthorizedID
+	q.ClassifierField, q.ClassifierValue = f.ClassifierField, f.ClassifierValue
+	return q
+}
+
+// RideReportResponse is the response returned by the API
+// when listing a page of completed rides.
+type RideReportResponse struct {
+	Status  ReqStatus
+	Message FlexString
+
+	Data []CompletedRide
+	// Page is the number of the page, starting at 1.
+	Page int
+	// TotalPages is the number of pages, zero if unknown.
+	TotalPages int
+
+	// location is the time zone of the rides, see Client.SetLocation.
+	location *time.Location
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// RideReportResponse type.
+func (rr *RideReportResponse) UnmarshalJSON(b []byte) error {
+	var (
+		r    listResponse
+		data []CompletedRide
+	)
+	err := r.each(b, func(v json.RawMessage) error {
+		var cr CompletedRide
+		if err := cr.decode(v, rr.location); err != nil {
+			return err
+		}
+		data = append(data, cr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*rr = RideReportResponse{
+		Status:     r.Status,
+		Message:    r.Message,
+		Data:       data,
+		Page:       int(r.Page),
+		TotalPages: int(r.TotalPages),
+		location:   rr.location,
+	}
+	return nil
+}
+
+func (rr *RideReportResponse) setLocation(loc *time.Location) {
+	rr.location = loc
+}
+
+func (rr *RideReportResponse) page() (ReqStatus, FlexString, interface{}, int) {
+	return rr.Status, rr.Message, rr.Data, rr.TotalPages
+}
+
+// ReportService handles the requests related to the reports.
+type ReportService service
+
+// RidesPage returns the page of completed rides, starting at 1, or an error.
+func (rs *ReportService) RidesPage(ctx context.Context, f *RideReportFilter, page int) (*RideReportResponse, error) {
+	rr := &RideReportResponse{}
+
+	if err := rs.client.Request(ctx, http.MethodPost, rideReportEndpoint, f.query(page), rr); err != nil {
+		return rr, err
+	}
+
+	return rr, nil
+}
+
+// Rides returns an iterator over the completed rides,
+// requesting the pages as needed.
+func (rs *ReportService) Rides(f *RideReportFilter) *RideIterator {
+	return &RideIterator{NewIterator(listPages("rides", func(ctx context.Context, page int) (pageResponse, error) {
+		return rs.RidesPage(ctx, f, page)
+	}))}
+}
+
+// RideIterator iterates over the completed rides of a report.
+type RideIterator struct {
+	*Iterator
+}
+
+// Ride returns the current ride.
+func (it *RideIterator) Ride() CompletedRide {
+	r, _ := it.Value().(CompletedRide)
+	return r
+}
+
+// Columns of the CSV written by ExportCSV, followed
+// by one column per classifier field exported.
+var rideCSVHeader = []string{
+	"ride_id", "authorized_id", "user_name", "requested_at", "completed_at",
+	"origin", "destination", "category", "distance_km", "duration_min",
+	"fare", "currency", "driver_name", "vehicle", "license_plate",
+}
+
+// ExportCSV writes the completed rides to w as CSV, with a header and one
+// column per classifier field given, e.g. "1" for the cost center kept in
+// classificador1. It stops at the first error, returning it.
+func (rs *ReportService) ExportCSV(ctx context.Context, w io.Writer, f *RideReportFilter, classifiers ...string) error {
+	cw := csv.NewWriter(w)
+
+	header := append([]string(nil), rideCSVHeader...)
+	for _, c := range classifiers {
+		header = append(header, "classificador"+c)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	it := rs.Rides(f)
+	defer it.Stop()
+
+	for it.Next(ctx) {
+		if err := cw.Write(rideCSVRecord(it.Ride(), classifiers)); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func rideCSVRecord(r CompletedRide, classifiers []string) []string {
+	rec := []string{
+		r.ID, r.AuthorizedID, r.UserName, csvTime(r.RequestedAt), csvTime(r.CompletedAt),
+		r.Origin.Address, r.Destination.Address, r.Category,
+		strconv.FormatFloat(r.Distance, 'f', 2, 64),
+		strconv.FormatFloat(r.Duration.Minutes(), 'f', 1, 64),
+		strconv.FormatFloat(r.Fare, 'f', 2, 64), r.Currency,
+		r.Driver.Name, r.Driver.Vehicle, r.Driver.LicensePlate,
+	}
+	for _, c := range classifiers {
+		rec = append(rec, r.Classifiers[c])
+	}
+	return rec
+}
+
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(reportTimeLayouts[0])
+}
@@ -0,0 +1,283 @@
+package liguetaxi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompletedRideUnmarshalJSON(t *testing.T) {
+	b := `{
+		"ride_id": 987,
+		"authorized_id": "123",
+		"client_name": "João da Silva",
+		"classificador1": "CC-100",
+		"classificador2": {},
+		"requested_at": "2026-03-02 08:15:00",
+		"completed_at": "2026-03-02T09:00:00Z",
+		"origin": {"address": "Av. Paulista, 1000", "lat": "-23.5652", "lng": -46.652},
+		"destination": {"address": "Aeroporto de Guarulhos", "lat": -23.4356, "lng": "-46.4731"},
+		"category": "executivo",
+		"distance": "30.2",
+		"duration": 2700,
+		"fare": "98.50",
+		"currency": "BRL",
+		"driver": {"name": "José", "phone": 11999999999, "vehicle": "Corolla", "license_plate": "ABC1D23"}
+	}`
+
+	want := CompletedRide{
+		ID:           "987",
+		AuthorizedID: "123",
+		UserName:     "João da Silva",
+		Classifiers:  map[string]string{"1": "CC-100"},
+		RequestedAt:  time.Date(2026, 3, 2, 8, 15, 0, 0, defaultLocation),
+		CompletedAt:  time.Date(2026, 3, 2, 6, 0, 0, 0, defaultLocation),
+		Origin:       Location{"Av. Paulista, 1000", -23.5652, -46.652},
+		Destination:  Location{"Aeroporto de Guarulhos", -23.4356, -46.4731},
+		Category:     "executivo",
+		Distance:     30.2,
+		Duration:     45 * time.Minute,
+		Fare:         98.5,
+		Currency:     "BRL",
+		Driver:       Driver{"José", "11999999999", "Corolla", "ABC1D23"},
+	}
+
+	var got CompletedRide
+	if err := json.Unmarshal([]byte(b), &got); err != nil {
+		t.Fatalf("got error unmarshaling CompletedRide: %s; want nil.", err.Error())
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got CompletedRide %+v; want %+v.", got, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"requested_at":"02/03/2026"}`), &got); err == nil {
+		t.Error("got nil error unmarshaling CompletedRide with invalid time; want error.")
+	}
+}
+
+// reportRequester serves the pages of rides, keeping the queries received.
+func reportRequester(pages [][]string, totalPages int, queries *[]rideReportQuery) requesterFunc {
+	return func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		q := body.(rideReportQuery)
+		*queries = append(*queries, q)
+
+		rr := output.(*RideReportResponse)
+		rr.Status, rr.Page, rr.TotalPages = ReqStatusOK, q.Page, totalPages
+		if q.Page <= len(pages) {
+			for _, id := range pages[q.Page-1] {
+				rr.Data = append(rr.Data, CompletedRide{ID: id})
+			}
+		}
+		return nil
+	}
+}
+
+func TestReportRides(t *testing.T) {
+	pages := [][]string{{"1", "2"}, {"3", "4"}, {"5"}}
+
+	testCases := []struct {
+		name       string
+		totalPages int
+		wantPages  int
+	}{
+		{"Total pages", 3, 3},
+		{"Unknown total pages", 0, 4},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // creates scoped test case
+		t.Run(tc.name, func(t *testing.T) {
+			var queries []rideReportQuery
+
+			rs := &ReportService{reportRequester(pages, tc.totalPages, &queries)}
+			it := rs.Rides(&RideReportFilter{
+				From:            time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:              time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+				ClassifierField: "1",
+				ClassifierValue: "CC-100",
+				PageSize:        2,
+			})
+
+			var ids []string
+			for it.Next(context.Background()) {
+				ids = append(ids, it.Ride().ID)
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatalf("got error iterating rides: %s; want nil.", err.Error())
+			}
+
+			if want := []string{"1", "2", "3", "4", "5"}; !reflect.DeepEqual(ids, want) {
+				t.Errorf("got rides %v; want %v.", ids, want)
+			}
+
+			if len(queries) != tc.wantPages {
+				t.Fatalf("got %d pages requested; want %d.", len(queries), tc.wantPages)
+			}
+
+			want := rideReportQuery{"2026-03-01", "2026-03-31", "", "1", "CC-100", 1, 2}
+			if queries[0] != want {
+				t.Errorf("got query %+v; want %+v.", queries[0], want)
+			}
+		})
+	}
+}
+
+func TestReportRidesError(t *testing.T) {
+	err := errors.New("Error")
+
+	testCases := []struct {
+		name    string
+		status  ReqStatus
+		data    []CompletedRide
+		err     error
+		wantErr string
+	}{
+		{"Request error", ReqStatusOK, nil, err, "Error"},
+		{"Status fail", ReqStatusFail, []CompletedRide{{ID: "2"}}, nil, "liguetaxi: listing rides page 2 failed: Invalid page"},
+		// The API fails the empty pages.
+		{"Empty", ReqStatusFail, nil, nil, ""},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // creates scoped test case
+		t.Run(tc.name, func(t *testing.T) {
+			rs := &ReportService{requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+				rr := output.(*RideReportResponse)
+				if body.(rideReportQuery).Page == 1 {
+					rr.Status, rr.Data = ReqStatusOK, []CompletedRide{{ID: "1"}}
+					return nil
+				}
+
+				rr.Status, rr.Message, rr.Data = tc.status, "Invalid page", tc.data
+				return tc.err
+			})}
+
+			it := rs.Rides(nil)
+
+			var n int
+			for it.Next(context.Background()) {
+				n++
+			}
+
+			if n != 1 {
+				t.Errorf("got %d rides; want 1.", n)
+			}
+
+			if tc.wantErr == "" && it.Err() != nil || tc.wantErr != "" && (it.Err() == nil || it.Err().Error() != tc.wantErr) {
+				t.Errorf("got error: %v; want %q.", it.Err(), tc.wantErr)
+			}
+
+			if it.Next(context.Background()) {
+				t.Error("got Next() true after the end; want false.")
+			}
+		})
+	}
+}
+
+func TestReportExportCSV(t *testing.T) {
+	rs := &ReportService{requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		if method != http.MethodPost || path != rideReportEndpoint {
+			t.Errorf("got request %s %s; want %s %s.", method, path, http.MethodPost, rideReportEndpoint)
+		}
+
+		rr := output.(*RideReportResponse)
+		rr.Status, rr.TotalPages = ReqStatusOK, 1
+		rr.Data = []CompletedRide{{
+			ID:           "987",
+			AuthorizedID: "123",
+			UserName:     "Silva, João",
+			Classifiers:  map[string]string{"1": "CC-100"},
+			RequestedAt:  time.Date(2026, 3, 2, 8, 15, 0, 0, time.UTC),
+			Origin:       Location{Address: "Av. Paulista, 1000"},
+			Destination:  Location{Address: "Aeroporto de Guarulhos"},
+			Category:     "executivo",
+			Distance:     30.2,
+			Duration:     45 * time.Minute,
+			Fare:         98.5,
+			Currency:     "BRL",
+			Driver:       Driver{Name: "José", Vehicle: "Corolla", LicensePlate: "ABC1D23"},
+		}}
+		return nil
+	})}
+
+	var buf bytes.Buffer
+	if err := rs.ExportCSV(context.Background(), &buf, nil, "1", "2"); err != nil {
+		t.Fatalf("got error exporting CSV: %s; want nil.", err.Error())
+	}
+
+	want := "ride_id,authorized_id,user_name,requested_at,completed_at,origin,destination,category," +
+		"distance_km,duration_min,fare,currency,driver_name,vehicle,license_plate,classificador1,classificador2\n" +
+		`987,123,"Silva, João",2026-03-02 08:15:00,,"Av. Paulista, 1000",Aeroporto de Guarulhos,executivo,` +
+		"30.20,45.0,98.50,BRL,José,Corolla,ABC1D23,CC-100,\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("got CSV:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseReportTime(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*60*60)
+
+	testCases := []struct {
+		s    string
+		want time.Time
+	}{
+		// The last ride of March is not moved to April, as in UTC.
+		{"2026-03-31 22:30:00", time.Date(2026, 3, 31, 22, 30, 0, 0, loc)},
+		{"2026-04-01T01:30:00Z", time.Date(2026, 3, 31, 22, 30, 0, 0, loc)},
+		{"2026-03-31", time.Date(2026, 3, 31, 0, 0, 0, 0, loc)},
+		{"", time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseReportTime(tc.s, loc)
+		if err != nil {
+			t.Fatalf("got error parsing %q: %s; want nil.", tc.s, err.Error())
+		}
+
+		if !got.Equal(tc.want) || (!got.IsZero() && got.Location() != loc) {
+			t.Errorf("got time %s from %q; want %s.", got, tc.s, tc.want)
+		}
+	}
+
+	if defaultLocation.String() != "America/Sao_Paulo" {
+		t.Errorf("got default location %s; want America/Sao_Paulo.", defaultLocation)
+	}
+}
+
+func TestClientSetLocation(t *testing.T) {
+	s := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":1,"data":[{"ride_id":"1","requested_at":"2026-03-31 22:30:00","pickup_time":"2026-03-31 22:30:00"}]}`))
+	})
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := NewClient(u, "abc", nil)
+	c.SetLocation(time.UTC)
+
+	want := time.Date(2026, 3, 31, 22, 30, 0, 0, time.UTC)
+
+	rr, err := c.Report.RidesPage(context.Background(), nil, 1)
+	if err != nil {
+		t.Fatalf("got error calling Report.RidesPage(): %s; want nil.", err.Error())
+	}
+	if len(rr.Data) != 1 || !rr.Data[0].RequestedAt.Equal(want) || rr.Data[0].RequestedAt.Location() != time.UTC {
+		t.Errorf("got rides %+v; want requested at %s.", rr.Data, want)
+	}
+
+	sr, err := c.Ride.ListScheduled(context.Background(), "123")
+	if err != nil {
+		t.Fatalf("got error calling Ride.ListScheduled(): %s; want nil.", err.Error())
+	}
+	if len(sr.Data) != 1 || !sr.Data[0].PickupTime.Equal(want) || sr.Data[0].PickupTime.Location() != time.UTC {
+		t.Errorf("got rides %+v; want pickup at %s.", sr.Data, want)
+	}
+}
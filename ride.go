@@ -0,0 +1,519 @@
+package liguetaxi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrPastPickup is returned when scheduling or rescheduling
+// a ride with a pickup time that is not in the future.
+var ErrPastPickup = errors.New("liguetaxi: pickup time is in the past")
+
+// Unverified endpoints, see the README.
+var (
+	// Endpoint for scheduling ride.
+	scheduleRideEndpoint endpoint = `ride/schedule`
+
+	// Endpoint for listing scheduled rides.
+	listScheduledRidesEndpoint endpoint = `ride/list_scheduled`
+
+	// Endpoint for editing scheduled ride pickup time.
+	rescheduleRideEndpoint endpoint = `ride/reschedule`
+
+	// Endpoint for cancelling scheduled ride.
+	cancelScheduledRideEndpoint endpoint = `ride/cancel_scheduled`
+
+	// Endpoint for estimating ride fare.
+	estimateFareEndpoint endpoint = `ride/estimate`
+
+	// Endpoint for reading ride status.
+	rideStatusEndpoint endpoint = `ride/status`
+)
+
+// Ride statuses. Unverified, see the README.
+const (
+	RideStatusRequested      RideStatusCode = "requested"
+	RideStatusAccepted       RideStatusCode = "accepted"
+	RideStatusDriverArriving RideStatusCode = "driver_arriving"
+	RideStatusInProgress     RideStatusCode = "in_progress"
+	RideStatusFinished       RideStatusCode = "finished"
+	RideStatusCancelled      RideStatusCode = "cancelled"
+)
+
+// Polling intervals of WatchRide by ride status. While the ride does
+// not change, the interval grows up to watchMaxInterval.
+var (
+	watchIntervals = map[RideStatusCode]time.Duration{
+		RideStatusRequested:      10 * time.Second,
+		RideStatusAccepted:       5 * time.Second,
+		RideStatusDriverArriving: 5 * time.Second,
+		RideStatusInProgress:     30 * time.Second,
+	}
+	watchDefaultInterval = 10 * time.Second
+	watchMaxInterval     = time.Minute
+
+	// Number of consecutive failed polls after which WatchRide gives up.
+	watchMaxFailures = 5
+)
+
+// timeNow returns the current time, pulled off for testing.
+var timeNow = time.Now
+
+// Location is an address and its coordinates.
+type Location struct {
+	Address string  `json:"address"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+}
+
+// flexLocation decodes a Location, tolerating the quirks of the API.
+type flexLocation struct {
+	Address FlexString `json:"address"`
+	Lat     FlexFloat  `json:"lat"`
+	Lng     FlexFloat  `json:"lng"`
+}
+
+func (f flexLocation) location() Location {
+	return Location{f.Address.String(), f.Lat.Float(), f.Lng.Float()}
+}
+
+// Coordinates are the latitude and longitude of a place.
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// ScheduledRide is the ride booked for a future pickup time.
+type ScheduledRide struct {
+	ID           FlexString `json:"ride_id,omitempty"`
+	AuthorizedID string     `json:"authorized_id"`
+	PickupTime   time.Time  `json:"pickup_time"`
+	Origin       Location   `json:"origin"`
+	Destination  Location   `json:"destination"`
+	Passengers   int        `json:"passengers,omitempty"`
+	Notes        string     `json:"notes,omitempty"`
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for ScheduledRide
+// type, tolerating the quirks of the API. The pickup time sent without
+// zone is taken as in America/Sao_Paulo, see Client.SetLocation.
+func (sr *ScheduledRide) UnmarshalJSON(b []byte) error {
+	return sr.decode(b, nil)
+}
+
+// decode decodes the ride b, taking the pickup time
+// sent without zone as in loc, or the default if nil.
+func (sr *ScheduledRide) decode(b []byte, loc *time.Location) error {
+	var f struct {
+		ID           FlexString   `json:"ride_id"`
+		AuthorizedID FlexString   `json:"authorized_id"`
+		PickupTime   FlexString   `json:"pickup_time"`
+		Origin       flexLocation `json:"origin"`
+		Destination  flexLocation `json:"destination"`
+		Passengers   FlexInt      `json:"passengers"`
+		Notes        FlexString   `json:"notes"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+
+	pickup, err := parseReportTime(f.PickupTime.String(), orDefaultLocation(loc))
+	if err != nil {
+		return err
+	}
+
+	*sr = ScheduledRide{
+		ID:           f.ID,
+		AuthorizedID: f.AuthorizedID.String(),
+		PickupTime:   pickup,
+		Origin:       f.Origin.location(),
+		Destination:  f.Destination.location(),
+		Passengers:   int(f.Passengers.Int()),
+		Notes:        f.Notes.String(),
+	}
+	return nil
+}
+
+// ScheduledRidesResponse is the response returned by the API
+// when listing the scheduled rides of a user.
+type ScheduledRidesResponse struct {
+	Status ReqStatus
+
+	Data []ScheduledRide `json:"data"`
+
+	// location is the time zone of the rides, see Client.SetLocation.
+	location *time.Location
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// ScheduledRidesResponse type. A single ride is decoded
+// as a list of one.
+func (sr *ScheduledRidesResponse) UnmarshalJSON(b []byte) error {
+	var (
+		r    listResponse
+		data []ScheduledRide
+	)
+	err := r.each(b, func(v json.RawMessage) error {
+		var ride ScheduledRide
+		if err := ride.decode(v, sr.location); err != nil {
+			return err
+		}
+		data = append(data, ride)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*sr = ScheduledRidesResponse{Status: r.Status, Data: data, location: sr.location}
+	return nil
+}
+
+func (sr *ScheduledRidesResponse) setLocation(loc *time.Location) {
+	sr.location = loc
+}
+
+// FareEstimateRequest is sent to server when estimating the fare
+// of a ride for the user with the authorized ID.
+type FareEstimateRequest struct {
+	AuthorizedID string      `json:"authorized_id"`
+	Origin       Coordinates `json:"origin"`
+	Destination  Coordinates `json:"destination"`
+	// Category is the ride category, e.g. "executivo". If empty,
+	// the API estimates the fare of the default category.
+	Category string `json:"category,omitempty"`
+}
+
+// FareEstimate is the estimated fare of a ride.
+type FareEstimate struct {
+	Value    float64
+	Currency string
+	// Distance is the distance of the ride, in kilometers.
+	Distance float64
+	Duration time.Duration
+	Category string
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// FareEstimate type. Numbers may be sent as strings, and
+// the duration is sent in seconds.
+func (fe *FareEstimate) UnmarshalJSON(b []byte) error {
+	var f struct {
+		Value    FlexFloat  `json:"value"`
+		Currency FlexString `json:"currency"`
+		Distance FlexFloat  `json:"distance"`
+		Duration FlexFloat  `json:"duration"`
+		Category FlexString `json:"category"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+
+	*fe = FareEstimate{
+		Value:    f.Value.Float(),
+		Currency: f.Currency.String(),
+		Distance: f.Distance.Float(),
+		Duration: seconds(f.Duration),
+		Category: f.Category.String(),
+	}
+	return nil
+}
+
+// seconds returns the duration of s seconds.
+func seconds(s FlexFloat) time.Duration {
+	return time.Duration(s.Float() * float64(time.Second))
+}
+
+// Exceeds reports whether the estimated value is greater than the budget.
+func (fe FareEstimate) Exceeds(budget float64) bool {
+	return fe.Value > budget
+}
+
+// FareEstimateResponse is the response returned
+// by the API when estimating a ride fare.
+type FareEstimateResponse struct {
+	Status ReqStatus
+
+	Data FareEstimate `json:"data"`
+}
+
+// Pulled off for testing
+type rideFilter struct {
+	AuthorizedID string `json:"authorized_id"`
+}
+
+// rideReschedule is sent to server when rescheduling ride.
+type rideReschedule struct {
+	ID         string    `json:"ride_id"`
+	PickupTime time.Time `json:"pickup_time"`
+}
+
+// rideCancel is sent to server when cancelling ride.
+type rideCancel struct {
+	ID     string `json:"ride_id"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RideService handles the requests related to the rides.
+type RideService service
+
+// Schedule returns the status operation for scheduling the ride or an error.
+// The ID of the scheduled ride is returned as the CreatedID of the response.
+func (rs *RideService) Schedule(ctx context.Context, r *ScheduledRide) (*OperationResponse, error) {
+	op := &OperationResponse{}
+
+	if r != nil && !r.PickupTime.After(timeNow()) {
+		return op, ErrPastPickup
+	}
+
+	if err := rs.client.Request(ctx, http.MethodPost, scheduleRideEndpoint, r, op); err != nil {
+		return op, err
+	}
+
+	return op, nil
+}
+
+// ListScheduled returns the scheduled rides of the user
+// with the authorized ID or an error.
+func (rs *RideService) ListScheduled(ctx context.Context, authorizedID string) (*ScheduledRidesResponse, error) {
+	sr := &ScheduledRidesResponse{}
+
+	if err := rs.client.Request(ctx, http.MethodPost, listScheduledRidesEndpoint, rideFilter{authorizedID}, sr); err != nil {
+		return sr, err
+	}
+
+	return sr, nil
+}
+
+// Reschedule returns the status operation for changing the pickup
+// time of the scheduled ride or an error.
+func (rs *RideService) Reschedule(ctx context.Context, id string, pickup time.Time) (*OperationResponse, error) {
+	op := &OperationResponse{}
+
+	if !pickup.After(timeNow()) {
+		return op, ErrPastPickup
+	}
+
+	if err := rs.client.Request(ctx, http.MethodPost, rescheduleRideEndpoint, rideReschedule{id, pickup}, op); err != nil {
+		return op, err
+	}
+
+	return op, nil
+}
+
+// Cancel returns the status operation for cancelling the
+// scheduled ride for the reason or an error.
+func (rs *RideService) Cancel(ctx context.Context, id, reason string) (*OperationResponse, error) {
+	op := &OperationResponse{}
+
+	if err := rs.client.Request(ctx, http.MethodPost, cancelScheduledRideEndpoint, rideCancel{id, reason}, op); err != nil {
+		return op, err
+	}
+
+	return op, nil
+}
+
+// Estimate returns the estimated fare of the ride or an error.
+func (rs *RideService) Estimate(ctx context.Context, r *FareEstimateRequest) (*FareEstimateResponse, error) {
+	fe := &FareEstimateResponse{}
+
+	if err := rs.client.Request(ctx, http.MethodPost, estimateFareEndpoint, r, fe); err != nil {
+		return fe, err
+	}
+
+	return fe, nil
+}
+
+// RideStatusCode is the status of a ride.
+type RideStatusCode string
+
+// Terminal reports whether the ride status is final,
+// i.e. the ride finished or was cancelled.
+func (s RideStatusCode) Terminal() bool {
+	return s == RideStatusFinished || s == RideStatusCancelled
+}
+
+// RideStatus is the current status of a ride.
+type RideStatus struct {
+	RideID string
+	Status RideStatusCode
+	Driver Driver
+	// DriverPosition is the last known position of the driver.
+	DriverPosition Coordinates
+	// ETA is the estimated time until the driver arrives,
+	// zero if unknown.
+	ETA time.Duration
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// RideStatus type, tolerating the quirks of the API.
+func (rs *RideStatus) UnmarshalJSON(b []byte) error {
+	var f struct {
+		RideID FlexString `json:"ride_id"`
+		Status FlexString `json:"status"`
+		ETA    FlexFloat  `json:"eta"`
+		Driver struct {
+			Name         FlexString `json:"name"`
+			Phone        FlexString `json:"phone"`
+			Vehicle      FlexString `json:"vehicle"`
+			LicensePlate FlexString `json:"license_plate"`
+			Lat          FlexFloat  `json:"lat"`
+			Lng          FlexFloat  `json:"lng"`
+		} `json:"driver"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+
+	*rs = RideStatus{
+		RideID: f.RideID.String(),
+		Status: RideStatusCode(f.Status),
+		Driver: Driver{
+			f.Driver.Name.String(),
+			f.Driver.Phone.String(),
+			f.Driver.Vehicle.String(),
+			f.Driver.LicensePlate.String(),
+		},
+		DriverPosition: Coordinates{f.Driver.Lat.Float(), f.Driver.Lng.Float()},
+		ETA:            seconds(f.ETA),
+	}
+	return nil
+}
+
+// RideStatusResponse is the response returned by
+// the API when reading the ride status.
+type RideStatusResponse struct {
+	Status ReqStatus
+
+	Data RideStatus `json:"data"`
+}
+
+// Pulled off for testing
+type rideStatusFilter struct {
+	ID string `json:"ride_id"`
+}
+
+// Status returns the current status of the ride or an error.
+func (rs *RideService) Status(ctx context.Context, id string) (*RideStatusResponse, error) {
+	sr := &RideStatusResponse{}
+
+	if err := rs.client.Request(ctx, http.MethodPost, rideStatusEndpoint, rideStatusFilter{id}, sr); err != nil {
+		return sr, err
+	}
+
+	return sr, nil
+}
+
+// RideUpdate is the change of a ride watched by WatchRide.
+type RideUpdate struct {
+	// Ride is the ride after the change.
+	Ride RideStatus
+	// Previous is the status of the ride before the
+	// change, empty for the first event.
+	Previous RideStatusCode
+	// Err, if not nil, is the error of the last poll, in which
+	// case Ride is the last known state. The watch goes on, unless
+	// the API answered with a failed status, e.g. for an unknown
+	// ride, or the polls failed watchMaxFailures times in a row.
+	Err error
+}
+
+// WatchRide polls the status of the ride, sending an event on the
+// returned channel whenever the ride changes, e.g. its status, the
+// driver position or the ETA. The polling interval depends on the ride
+// status, being shorter while the driver is on the way, and grows while
+// the ride does not change. The channel is closed after the terminal
+// status is sent, after an update with an error that ends the watch, see
+// RideUpdate, or when the context is done.
+func (rs *RideService) WatchRide(ctx context.Context, id string) <-chan RideUpdate {
+	ch := make(chan RideUpdate)
+
+	go func() {
+		defer close(ch)
+
+		var (
+			last     RideStatus
+			seen     bool
+			interval time.Duration
+			failures int
+		)
+		for {
+			sr, err := rs.Status(ctx, id)
+			if ctx.Err() != nil {
+				return
+			}
+
+			switch {
+			case err == nil && sr.Status != ReqStatusOK:
+				// The ride is unknown or cannot be read, which
+				// polling again does not change.
+				rs.send(ctx, ch, RideUpdate{Ride: last, Err: fmt.Errorf("liguetaxi: reading ride %s status failed", id)})
+				return
+			case err != nil:
+				failures++
+				if !rs.send(ctx, ch, RideUpdate{Ride: last, Err: err}) || failures >= watchMaxFailures {
+					return
+				}
+				interval = nextInterval(interval, last.Status, false)
+			case !seen || sr.Data != last:
+				e := RideUpdate{Ride: sr.Data}
+				if seen {
+					e.Previous = last.Status
+				}
+				last, seen = sr.Data, true
+
+				if !rs.send(ctx, ch, e) || last.Status.Terminal() {
+					return
+				}
+				interval = nextInterval(interval, last.Status, true)
+			default:
+				interval = nextInterval(interval, last.Status, false)
+			}
+			if err == nil {
+				failures = 0
+			}
+
+			t := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	return ch
+}
+
+// send sends the event, reporting false if the context is done first.
+func (rs *RideService) send(ctx context.Context, ch chan<- RideUpdate, e RideUpdate) bool {
+	select {
+	case ch <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// nextInterval returns the polling interval after a poll. It is reset
+// to the interval of the status on changes, growing by half otherwise.
+func nextInterval(cur time.Duration, s RideStatusCode, changed bool) time.Duration {
+	base, ok := watchIntervals[s]
+	if !ok {
+		base = watchDefaultInterval
+	}
+
+	if changed || cur == 0 {
+		return base
+	}
+
+	if cur += cur / 2; cur > watchMaxInterval {
+		cur = watchMaxInterval
+	}
+	return cur
+}
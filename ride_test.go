@@ -0,0 +1,560 @@
+package liguetaxi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduledRidesResponseUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b    string
+		want []ScheduledRide
+	}{
+		{`{"status":1,"data":{}}`, nil},
+		{`{"status":1,"data":{"ride_id":1,"authorized_id":"123"}}`, []ScheduledRide{{ID: "1", AuthorizedID: "123"}}},
+		{
+			`{"status":1,"data":[{"ride_id":"1","passengers":2},{"ride_id":"2","notes":"Terminal 2"}]}`,
+			[]ScheduledRide{{ID: "1", Passengers: 2}, {ID: "2", Notes: "Terminal 2"}},
+		},
+		{
+			`{"status":1,"data":{"ride_id":"3","authorized_id":123,"pickup_time":"2026-03-10 08:30:00",` +
+				`"origin":{"address":"Av. Paulista, 1000","lat":"-23.5652","lng":-46.652},"passengers":"2"}}`,
+			[]ScheduledRide{{
+				ID:           "3",
+				AuthorizedID: "123",
+				PickupTime:   time.Date(2026, 3, 10, 8, 30, 0, 0, defaultLocation),
+				Origin:       Location{"Av. Paulista, 1000", -23.5652, -46.652},
+				Passengers:   2,
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		var got ScheduledRidesResponse
+		if err := json.Unmarshal([]byte(tc.b), &got); err != nil {
+			t.Fatalf("got error unmarshaling ScheduledRidesResponse %s: %s; want nil.", tc.b, err.Error())
+		}
+
+		if got.Status != ReqStatusOK {
+			t.Errorf("got status %d; want %d.", got.Status, ReqStatusOK)
+		}
+
+		if !reflect.DeepEqual(got.Data, tc.want) {
+			t.Errorf("got rides from %s: %+v; want %+v.", tc.b, got.Data, tc.want)
+		}
+	}
+}
+
+func TestFareEstimateUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b       string
+		want    FareEstimate
+		wantErr bool
+	}{
+		{
+			`{"value":45.9,"currency":"BRL","distance":12.5,"duration":1500,"category":"executivo"}`,
+			FareEstimate{45.9, "BRL", 12.5, 25 * time.Minute, "executivo"},
+			false,
+		},
+		{
+			`{"value":"45.90","currency":"BRL","distance":"12.5","duration":"90","category":{}}`,
+			FareEstimate{45.9, "BRL", 12.5, 90 * time.Second, ""},
+			false,
+		},
+		{`{"value":{},"distance":[]}`, FareEstimate{}, false},
+		{`{"value":"R$ 45,90"}`, FareEstimate{}, true},
+	}
+
+	for _, tc := range testCases {
+		var got FareEstimate
+		err := json.Unmarshal([]byte(tc.b), &got)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("got error unmarshaling FareEstimate %s: %v; want error %t.", tc.b, err, tc.wantErr)
+		}
+
+		if !tc.wantErr && got != tc.want {
+			t.Errorf("got FareEstimate from %s: %+v; want %+v.", tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestFareEstimateExceeds(t *testing.T) {
+	testCases := []struct {
+		value  float64
+		budget float64
+		want   bool
+	}{
+		{45.9, 50, false},
+		{50, 50, false},
+		{50.01, 50, true},
+	}
+
+	for _, tc := range testCases {
+		if got := (FareEstimate{Value: tc.value}).Exceeds(tc.budget); got != tc.want {
+			t.Errorf("got FareEstimate{Value: %v}.Exceeds(%v): %t; want %t.", tc.value, tc.budget, got, tc.want)
+		}
+	}
+}
+
+func TestRide(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	pickup := now.Add(48 * time.Hour)
+	ride := &ScheduledRide{
+		AuthorizedID: "123",
+		PickupTime:   pickup,
+		Origin:       Location{"Av. Paulista, 1000", -23.5652, -46.6520},
+		Destination:  Location{"Aeroporto de Guarulhos", -23.4356, -46.4731},
+		Passengers:   2,
+		Notes:        "Terminal 2",
+	}
+
+	estimate := &FareEstimateRequest{
+		AuthorizedID: "123",
+		Origin:       Coordinates{-23.5652, -46.6520},
+		Destination:  Coordinates{-23.4356, -46.4731},
+		Category:     "executivo",
+	}
+
+	testCases := []struct {
+		name    string
+		call    func(ctx context.Context, req requester) (resp interface{}, err error)
+		ctx     context.Context
+		method  string
+		path    endpoint
+		body    interface{}
+		wantRes interface{}
+	}{
+		{
+			"Schedule()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&RideService{req}).Schedule(ctx, ride)
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			scheduleRideEndpoint,
+			ride,
+			&OperationResponse{
+				Status:    ReqStatusOK,
+				CreatedID: "1",
+			},
+		},
+		{
+			"ListScheduled()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&RideService{req}).ListScheduled(ctx, "123")
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			listScheduledRidesEndpoint,
+			rideFilter{"123"},
+			&ScheduledRidesResponse{
+				Status: ReqStatusOK,
+				Data:   []ScheduledRide{*ride},
+			},
+		},
+		{
+			"Reschedule()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&RideService{req}).Reschedule(ctx, "1", pickup)
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			rescheduleRideEndpoint,
+			rideReschedule{"1", pickup},
+			&OperationResponse{
+				Status: ReqStatusOK,
+			},
+		},
+		{
+			"Cancel()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&RideService{req}).Cancel(ctx, "1", "Meeting cancelled")
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			cancelScheduledRideEndpoint,
+			rideCancel{"1", "Meeting cancelled"},
+			&OperationResponse{
+				Status: ReqStatusOK,
+			},
+		},
+		{
+			"Estimate()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&RideService{req}).Estimate(ctx, estimate)
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			estimateFareEndpoint,
+			estimate,
+			&FareEstimateResponse{
+				Status: ReqStatusOK,
+				Data:   FareEstimate{45.9, "BRL", 12.5, 25 * time.Minute, "executivo"},
+			},
+		},
+		{
+			"Status()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&RideService{req}).Status(ctx, "1")
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			rideStatusEndpoint,
+			rideStatusFilter{"1"},
+			&RideStatusResponse{
+				Status: ReqStatusOK,
+				Data:   RideStatus{RideID: "1", Status: RideStatusAccepted, ETA: 5 * time.Minute},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // creates scoped test case
+		t.Run(tc.name, func(t *testing.T) {
+			req := &testRequester{output: reflect.ValueOf(tc.wantRes).Elem()}
+
+			res, err := tc.call(tc.ctx, req)
+			if err != nil {
+				t.Fatalf("got error while calling Ride %s: %s, want nil", tc.name, err.Error())
+			}
+
+			if req.method != tc.method {
+				t.Errorf("got request method: %s; want %s.", req.method, tc.method)
+			}
+
+			if req.path != tc.path {
+				t.Errorf("got request path: %s; want %s.", req.path, tc.path)
+			}
+
+			if !reflect.DeepEqual(req.body, tc.body) {
+				t.Errorf("got request body: %+v; want %+v.", req.body, tc.body)
+			}
+
+			if !reflect.DeepEqual(res, tc.wantRes) {
+				t.Errorf("got response: %+v; want %+v.", res, tc.wantRes)
+			}
+		})
+	}
+}
+
+func TestRideError(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	testCases := []struct {
+		name string
+		call func(req requester) error
+		err  error
+	}{
+		{
+			"Schedule()",
+			func(req requester) error {
+				_, err := (&RideService{req}).Schedule(context.Background(), nil)
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"Schedule() past pickup",
+			func(req requester) error {
+				_, err := (&RideService{req}).Schedule(context.Background(), &ScheduledRide{PickupTime: now})
+				return err
+			},
+			ErrPastPickup,
+		},
+		{
+			"ListScheduled()",
+			func(req requester) error {
+				_, err := (&RideService{req}).ListScheduled(context.Background(), "123")
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"Reschedule()",
+			func(req requester) error {
+				_, err := (&RideService{req}).Reschedule(context.Background(), "1", now.Add(time.Hour))
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"Reschedule() past pickup",
+			func(req requester) error {
+				_, err := (&RideService{req}).Reschedule(context.Background(), "1", now.Add(-time.Hour))
+				return err
+			},
+			ErrPastPickup,
+		},
+		{
+			"Cancel()",
+			func(req requester) error {
+				_, err := (&RideService{req}).Cancel(context.Background(), "1", "")
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"Status()",
+			func(req requester) error {
+				_, err := (&RideService{req}).Status(context.Background(), "1")
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"Estimate()",
+			func(req requester) error {
+				_, err := (&RideService{req}).Estimate(context.Background(), nil)
+				return err
+			},
+			errors.New("Error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // creates scoped test case
+		t.Run(tc.name, func(t *testing.T) {
+			req := &testRequester{err: errors.New("Error")}
+
+			err := tc.call(req)
+			if !reflect.DeepEqual(err, tc.err) {
+				t.Errorf("got error: %s; want %s.", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestRideStatusUnmarshalJSON(t *testing.T) {
+	b := `{"ride_id":1,"status":"driver_arriving","eta":"120","driver":{"name":"José","vehicle":"Corolla","license_plate":"ABC1D23","lat":"-23.5","lng":-46.6}}`
+
+	want := RideStatus{
+		RideID:         "1",
+		Status:         RideStatusDriverArriving,
+		Driver:         Driver{Name: "José", Vehicle: "Corolla", LicensePlate: "ABC1D23"},
+		DriverPosition: Coordinates{-23.5, -46.6},
+		ETA:            2 * time.Minute,
+	}
+
+	var got RideStatus
+	if err := json.Unmarshal([]byte(b), &got); err != nil {
+		t.Fatalf("got error unmarshaling RideStatus: %s; want nil.", err.Error())
+	}
+
+	if got != want {
+		t.Errorf("got RideStatus %+v; want %+v.", got, want)
+	}
+}
+
+func TestRideStatusCodeTerminal(t *testing.T) {
+	testCases := []struct {
+		status RideStatusCode
+		want   bool
+	}{
+		{RideStatusRequested, false},
+		{RideStatusAccepted, false},
+		{RideStatusDriverArriving, false},
+		{RideStatusInProgress, false},
+		{RideStatusFinished, true},
+		{RideStatusCancelled, true},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.status.Terminal(); got != tc.want {
+			t.Errorf("got RideStatusCode(%s).Terminal(): %t; want %t.", tc.status, got, tc.want)
+		}
+	}
+}
+
+func TestNextInterval(t *testing.T) {
+	testCases := []struct {
+		cur     time.Duration
+		status  RideStatusCode
+		changed bool
+		want    time.Duration
+	}{
+		{0, RideStatusDriverArriving, false, 5 * time.Second},
+		{20 * time.Second, RideStatusDriverArriving, true, 5 * time.Second},
+		{10 * time.Second, RideStatusRequested, false, 15 * time.Second},
+		{50 * time.Second, RideStatusInProgress, false, time.Minute},
+		{0, RideStatusCode("unknown"), true, 10 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		if got := nextInterval(tc.cur, tc.status, tc.changed); got != tc.want {
+			t.Errorf("got nextInterval(%s, %s, %t): %s; want %s.", tc.cur, tc.status, tc.changed, got, tc.want)
+		}
+	}
+}
+
+// setWatchIntervals shortens the polling intervals of WatchRide,
+// returning the function that restores them.
+func setWatchIntervals() func() {
+	intervals, def, max := watchIntervals, watchDefaultInterval, watchMaxInterval
+
+	watchIntervals = map[RideStatusCode]time.Duration{}
+	watchDefaultInterval, watchMaxInterval = time.Millisecond, 2*time.Millisecond
+
+	return func() {
+		watchIntervals, watchDefaultInterval, watchMaxInterval = intervals, def, max
+	}
+}
+
+func TestWatchRide(t *testing.T) {
+	defer setWatchIntervals()()
+
+	err := errors.New("Error")
+	polls := []struct {
+		status RideStatusCode
+		eta    time.Duration
+		err    error
+	}{
+		{RideStatusRequested, 0, nil},
+		{RideStatusRequested, 0, nil},
+		{RideStatusAccepted, 5 * time.Minute, nil},
+		{RideStatusAccepted, 4 * time.Minute, nil},
+		{"", 0, err},
+		{RideStatusAccepted, 4 * time.Minute, nil},
+		{RideStatusFinished, 0, nil},
+		{RideStatusFinished, 0, nil},
+	}
+
+	var n int
+	rs := &RideService{requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		if path != rideStatusEndpoint || body.(rideStatusFilter).ID != "1" {
+			t.Errorf("got request to %s with body %+v; want %s with ride 1.", path, body, rideStatusEndpoint)
+		}
+
+		p := polls[n]
+		n++
+
+		if p.err != nil {
+			return p.err
+		}
+
+		o := output.(*RideStatusResponse)
+		o.Status, o.Data = ReqStatusOK, RideStatus{RideID: "1", Status: p.status, ETA: p.eta}
+		return nil
+	})}
+
+	var got []RideUpdate
+	for u := range rs.WatchRide(context.Background(), "1") {
+		got = append(got, u)
+	}
+
+	accepted := RideStatus{RideID: "1", Status: RideStatusAccepted, ETA: 4 * time.Minute}
+	want := []RideUpdate{
+		{Ride: RideStatus{RideID: "1", Status: RideStatusRequested}},
+		{Ride: RideStatus{RideID: "1", Status: RideStatusAccepted, ETA: 5 * time.Minute}, Previous: RideStatusRequested},
+		{Ride: accepted, Previous: RideStatusAccepted},
+		{Ride: accepted, Err: err},
+		{Ride: RideStatus{RideID: "1", Status: RideStatusFinished}, Previous: RideStatusAccepted},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got updates %+v; want %+v.", got, want)
+	}
+
+	if n != 7 {
+		t.Errorf("got %d polls; want 7.", n)
+	}
+}
+
+func TestWatchRideError(t *testing.T) {
+	defer setWatchIntervals()()
+
+	err := errors.New("Error")
+	tests := []struct {
+		name      string
+		err       error
+		wantPolls int
+	}{
+		{"Fail status", nil, 1},
+		{"Errors", err, watchMaxFailures},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var n int
+			rs := &RideService{requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+				n++
+				if tc.err != nil {
+					return tc.err
+				}
+
+				output.(*RideStatusResponse).Status = ReqStatusFail
+				return nil
+			})}
+
+			var got []RideUpdate
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for u := range rs.WatchRide(context.Background(), "1") {
+					got = append(got, u)
+				}
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("got channel open after failed polls; want closed.")
+			}
+
+			if n != tc.wantPolls || len(got) != tc.wantPolls {
+				t.Errorf("got %d polls and %d updates; want %d.", n, len(got), tc.wantPolls)
+			}
+
+			for _, u := range got {
+				if u.Err == nil {
+					t.Error("got update without error; want error.")
+				} else if tc.err != nil && u.Err != tc.err {
+					t.Errorf("got update error %v; want %v.", u.Err, tc.err)
+				}
+			}
+		})
+	}
+}
+
+func TestWatchRideCancel(t *testing.T) {
+	defer setWatchIntervals()()
+
+	rs := &RideService{requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		o := output.(*RideStatusResponse)
+		o.Status, o.Data = ReqStatusOK, RideStatus{RideID: "1", Status: RideStatusInProgress}
+		return nil
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := rs.WatchRide(ctx, "1")
+
+	if u := <-ch; u.Ride.Status != RideStatusInProgress {
+		t.Errorf("got status %s; want %s.", u.Ride.Status, RideStatusInProgress)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("got update after cancel; want channel closed.")
+		}
+	case <-time.After(time.Second):
+		t.Error("got channel open after cancel; want closed.")
+	}
+}
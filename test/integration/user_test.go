@@ -129,7 +129,7 @@ func checkUpdateStatus(u *liguetaxi.UserResponse) func() error {
 		}
 
 		if want := liguetaxi.ReqStatusOK; user.Status != want {
-			fmt.Errorf("got failed request. Status: %d; want %d.", user.Status, want)
+			return fmt.Errorf("got failed request. Status: %d; want %d.", user.Status, want)
 		}
 
 		if *user.Data.Status != liguetaxi.UserStatusSynching {
@@ -137,7 +137,7 @@ func checkUpdateStatus(u *liguetaxi.UserResponse) func() error {
 			return nil
 		}
 
-		return fmt.Errorf("Last status: %d.", *user.Data.Status)
+		return fmt.Errorf("Last status: %s.", user.Data.Status.Description())
 	}
 }
 
@@ -152,7 +152,7 @@ func TestUserUpdateStatus(t *testing.T) {
 	}
 
 	newUserStatus := &liguetaxi.UserStatus{
-		ID:     u.Data.ID,
+		ID:     u.Data.ID.String(),
 		Status: liguetaxi.UserStatusInactive,
 	}
 
@@ -171,7 +171,7 @@ func TestUserUpdateStatus(t *testing.T) {
 	}
 
 	if want := liguetaxi.UserStatusInactive; user.Data.ID != "" && *user.Data.Status != want {
-		t.Errorf("got user.Status: %d; want %d.", *user.Data.Status, want)
+		t.Errorf("got user.Status: %s; want %s.", user.Data.Status.Description(), want.Description())
 	}
 }
 
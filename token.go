@@ -0,0 +1,124 @@
+package liguetaxi
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource returns the token injected by the Transport
+// on the Authorization header of every request.
+// Implementations must be safe for concurrent use.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Refresher is implemented by the TokenSources whose token can be
+// renewed. The Transport calls Refresh when the API answers a
+// request with 401 Unauthorized, retrying it once with the new token.
+type Refresher interface {
+	Refresh() error
+}
+
+// StaticToken is a TokenSource that always returns the same token.
+type StaticToken string
+
+// Token implements the TokenSource interface.
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// BasicAuth returns a TokenSource with the Basic
+// token built from the username and password.
+func BasicAuth(username, password string) StaticToken {
+	return StaticToken(base64.StdEncoding.EncodeToString([]byte(username + ":" + password)))
+}
+
+// EnvToken is a TokenSource that reads the token from
+// the environment variable named by its value on every call.
+type EnvToken string
+
+// Token implements the TokenSource interface.
+func (e EnvToken) Token() (string, error) {
+	t, ok := os.LookupEnv(string(e))
+	if !ok || t == "" {
+		return "", fmt.Errorf("liguetaxi: environment variable %s not set", string(e))
+	}
+	return t, nil
+}
+
+// CachedTokenSource is a TokenSource that caches the token returned by a
+// fetch function, fetching it again when expired or refreshed.
+type CachedTokenSource struct {
+	mu      sync.Mutex
+	fetch   func() (string, error)
+	ttl     time.Duration
+	token   string
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewCachedTokenSource returns a CachedTokenSource that keeps the token
+// for ttl. A non-positive ttl keeps it until Refresh is called.
+func NewCachedTokenSource(fetch func() (string, error), ttl time.Duration) *CachedTokenSource {
+	return &CachedTokenSource{fetch: fetch, ttl: ttl, now: time.Now}
+}
+
+// FileTokenSource returns a CachedTokenSource that reads the token from
+// the file at path, reloading it every reload interval and on Refresh.
+// Surrounding white space is trimmed from the file content.
+func FileTokenSource(path string, reload time.Duration) *CachedTokenSource {
+	return NewCachedTokenSource(func() (string, error) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		t := strings.TrimSpace(string(b))
+		if t == "" {
+			return "", errors.New("liguetaxi: empty token file " + path)
+		}
+		return t, nil
+	}, reload)
+}
+
+// Token implements the TokenSource interface.
+func (s *CachedTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expires.IsZero() || s.now().Before(s.expires)) {
+		return s.token, nil
+	}
+
+	if err := s.refresh(); err != nil {
+		return "", err
+	}
+	return s.token, nil
+}
+
+// Refresh implements the Refresher interface.
+func (s *CachedTokenSource) Refresh() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.refresh()
+}
+
+func (s *CachedTokenSource) refresh() error {
+	t, err := s.fetch()
+	if err != nil {
+		return err
+	}
+
+	s.token, s.expires = t, time.Time{}
+	if s.ttl > 0 {
+		s.expires = s.now().Add(s.ttl)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package liguetaxi
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStaticToken(t *testing.T) {
+	testCases := []struct {
+		src  TokenSource
+		want string
+	}{
+		{StaticToken("abc"), "abc"},
+		{BasicAuth("user", "pass"), "dXNlcjpwYXNz"},
+	}
+
+	for _, tc := range testCases {
+		got, err := tc.src.Token()
+		if err != nil {
+			t.Fatalf("got error calling Token(): %s; want nil.", err.Error())
+		}
+
+		if got != tc.want {
+			t.Errorf("got Token(): %s; want %s.", got, tc.want)
+		}
+	}
+}
+
+func TestEnvToken(t *testing.T) {
+	const key = "LIGUETAXI_TEST_TOKEN"
+
+	os.Setenv(key, "abc")
+	defer os.Unsetenv(key)
+
+	if got, err := EnvToken(key).Token(); err != nil || got != "abc" {
+		t.Errorf("got EnvToken.Token(): %s, %v; want abc, nil.", got, err)
+	}
+
+	// The variable is read again on every call.
+	os.Setenv(key, "def")
+	if got, _ := EnvToken(key).Token(); got != "def" {
+		t.Errorf("got EnvToken.Token(): %s; want def.", got)
+	}
+
+	os.Unsetenv(key)
+	if _, err := EnvToken(key).Token(); err == nil {
+		t.Error("got error nil; want not nil.")
+	}
+}
+
+func TestCachedTokenSource(t *testing.T) {
+	var (
+		calls int
+		now   = time.Now()
+	)
+
+	s := NewCachedTokenSource(func() (string, error) {
+		calls++
+		return string(rune('a' + calls - 1)), nil
+	}, time.Minute)
+	s.now = func() time.Time { return now }
+
+	testCases := []struct {
+		step func()
+		want string
+	}{
+		{func() {}, "a"},
+		{func() {}, "a"},
+		{func() { now = now.Add(time.Minute) }, "b"},
+		{func() { s.Refresh() }, "c"},
+	}
+
+	for _, tc := range testCases {
+		tc.step()
+
+		got, err := s.Token()
+		if err != nil {
+			t.Fatalf("got error calling CachedTokenSource.Token(): %s; want nil.", err.Error())
+		}
+
+		if got != tc.want {
+			t.Errorf("got CachedTokenSource.Token(): %s; want %s.", got, tc.want)
+		}
+	}
+}
+
+func TestCachedTokenSourceError(t *testing.T) {
+	err := errors.New("Error")
+	s := NewCachedTokenSource(func() (string, error) { return "", err }, 0)
+
+	if _, e := s.Token(); e != err {
+		t.Errorf("got error: %v; want %v.", e, err)
+	}
+
+	if e := s.Refresh(); e != err {
+		t.Errorf("got error: %v; want %v.", e, err)
+	}
+}
+
+func TestFileTokenSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liguetaxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	ioutil.WriteFile(path, []byte("abc\n"), 0600)
+
+	s := FileTokenSource(path, 0)
+
+	if got, err := s.Token(); err != nil || got != "abc" {
+		t.Errorf("got FileTokenSource.Token(): %s, %v; want abc, nil.", got, err)
+	}
+
+	ioutil.WriteFile(path, []byte("def"), 0600)
+	if got, _ := s.Token(); got != "abc" {
+		t.Errorf("got FileTokenSource.Token(): %s; want abc before refresh.", got)
+	}
+
+	s.Refresh()
+	if got, _ := s.Token(); got != "def" {
+		t.Errorf("got FileTokenSource.Token(): %s; want def after refresh.", got)
+	}
+
+	ioutil.WriteFile(path, []byte(" "), 0600)
+	if err := s.Refresh(); err == nil {
+		t.Error("got error nil for empty file; want not nil.")
+	}
+
+	if _, err := FileTokenSource(filepath.Join(dir, "missing"), 0).Token(); err == nil {
+		t.Error("got error nil for missing file; want not nil.")
+	}
+}
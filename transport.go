@@ -1,6 +1,7 @@
 package liguetaxi
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
@@ -15,18 +16,93 @@ type Transport struct {
 
 	// Base is the base RoundTripper to make HTTP request.
 	Base http.RoundTripper
+
+	// Source, if not nil, is consulted on every request
+	// for the token, instead of Token.
+	Source TokenSource
 }
 
-// RoundTrip injects the Authorization Header with the Token
+// RoundTrip injects the Authorization Header with the Token, or
+// the token set in the request's context with WithToken, along
+// with the headers set in the context with WithHeader.
 func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	token, err := t.token(r.Context())
+	if err != nil {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, err
+	}
+
+	res, err := t.base().RoundTrip(authorize(r, token))
+	if err != nil || res == nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	return t.retry(r, res, token)
+}
+
+// retry refreshes the token source and sends the request
+// again, if the token changed and the body can be replayed.
+// Otherwise the unauthorized response is returned.
+func (t *Transport) retry(r *http.Request, res *http.Response, token string) (*http.Response, error) {
+	// Tokens set in the context are not refreshed.
+	if _, ok := contextToken(r.Context()); ok {
+		return res, nil
+	}
+
+	rf, ok := t.Source.(Refresher)
+	if !ok || (r.Body != nil && r.Body != http.NoBody && r.GetBody == nil) {
+		return res, nil
+	}
+
+	if err := rf.Refresh(); err != nil {
+		return res, nil
+	}
+
+	newToken, err := t.Source.Token()
+	if err != nil || newToken == token {
+		return res, nil
+	}
+
+	req := authorize(r, newToken)
+	if r.GetBody != nil {
+		if req.Body, err = r.GetBody(); err != nil {
+			return res, nil
+		}
+	}
+
+	res.Body.Close()
+	return t.base().RoundTrip(req)
+}
+
+// authorize returns a clone of the request with the headers
+// set in its context and the Authorization header.
+func authorize(r *http.Request, token string) *http.Request {
 	// We should not modify the origin request
-	// per RoundTripper contract. See 
+	// per RoundTripper contract. See
 	// https://golang.org/pkg/net/http/#RoundTripper
 	req := cloneReq(r)
+	for k, v := range contextHeader(r.Context()) {
+		req.Header[k] = append([]string(nil), v...)
+	}
 	// Injects the Authorization Header
-	req.Header.Set("Authorization", fmt.Sprintf("Basic %s", t.Token))
+	req.Header.Set("Authorization", fmt.Sprintf("Basic %s", token))
 
-	return t.base().RoundTrip(req)
+	return req
+}
+
+// token returns the token from the context, Source, if set, or Token.
+func (t *Transport) token(ctx context.Context) (string, error) {
+	if token, ok := contextToken(ctx); ok {
+		return token, nil
+	}
+
+	if t.Source != nil {
+		return t.Source.Token()
+	}
+
+	return t.Token, nil
 }
 
 // base returns nil if Base RoundTripper is nil
@@ -2,8 +2,10 @@ package liguetaxi
 
 import (
 	"errors"
-	"net/http/httptest"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -25,7 +27,7 @@ func TestRoundTrip(t *testing.T) {
 		return nil, nil
 	})
 
-	tr := &Transport{"abc", rt}
+	tr := &Transport{Token: "abc", Base: rt}
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	req.Header.Set("Content-Type", "application/json")
@@ -50,10 +52,112 @@ func TestRoundTripError(t *testing.T) {
 		return nil, errors.New("Error")
 	})
 
-	tr := &Transport{"abc", rt}
+	tr := &Transport{Token: "abc", Base: rt}
 
 	_, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil))
 	if err == nil {
 		t.Error("got error nil; want not nil")
 	}
 }
+
+func TestRoundTripTokenSource(t *testing.T) {
+	var auth string
+
+	rt := testRoundTripper(func(r *http.Request) (*http.Response, error) {
+		auth = r.Header.Get("Authorization")
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	tr := &Transport{Token: "abc", Base: rt, Source: StaticToken("def")}
+	tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if want := "Basic def"; auth != want {
+		t.Errorf("got Authorization Header: %s; want %s.", auth, want)
+	}
+}
+
+func TestRoundTripTokenSourceError(t *testing.T) {
+	rt := testRoundTripper(func(r *http.Request) (*http.Response, error) {
+		t.Error("expected Transport.RoundTrip() not to call base RoundTripper.")
+		return nil, nil
+	})
+
+	tr := &Transport{Base: rt, Source: EnvToken("LIGUETAXI_UNSET_TOKEN")}
+
+	if _, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil)); err == nil {
+		t.Error("got error nil; want not nil")
+	}
+}
+
+func TestRoundTripRefresh(t *testing.T) {
+	testCases := []struct {
+		name      string
+		tokens    []string
+		wantAuths []string
+		wantCode  int
+	}{
+		{
+			"refreshed",
+			[]string{"old", "new"},
+			[]string{"Basic old", "Basic new"},
+			http.StatusOK,
+		},
+		{
+			"unchanged",
+			[]string{"old", "old"},
+			[]string{"Basic old"},
+			http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // creates scoped test case
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				auths  []string
+				bodies []string
+				calls  int
+			)
+
+			rt := testRoundTripper(func(r *http.Request) (*http.Response, error) {
+				auth := r.Header.Get("Authorization")
+				auths = append(auths, auth)
+
+				b, _ := ioutil.ReadAll(r.Body)
+				bodies = append(bodies, string(b))
+
+				code := http.StatusUnauthorized
+				if auth == "Basic new" {
+					code = http.StatusOK
+				}
+				return &http.Response{StatusCode: code, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+			})
+
+			src := NewCachedTokenSource(func() (string, error) {
+				calls++
+				return tc.tokens[calls-1], nil
+			}, 0)
+
+			req, _ := http.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader("body"))
+
+			res, err := (&Transport{Base: rt, Source: src}).RoundTrip(req)
+			if err != nil {
+				t.Fatalf("got error calling Transport.RoundTrip(): %s; want nil.", err.Error())
+			}
+
+			if res.StatusCode != tc.wantCode {
+				t.Errorf("got status code: %d; want %d.", res.StatusCode, tc.wantCode)
+			}
+
+			if strings.Join(auths, ",") != strings.Join(tc.wantAuths, ",") {
+				t.Errorf("got Authorization Headers: %v; want %v.", auths, tc.wantAuths)
+			}
+
+			for i, b := range bodies {
+				if b != "body" {
+					t.Errorf("got body[%d]: %s; want body.", i, b)
+				}
+			}
+		})
+	}
+}
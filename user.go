@@ -3,16 +3,40 @@ package liguetaxi
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
+	"time"
 )
 
-// User statuses
+// User statuses, as the codes used by the API.
+// The zero value is sent as UserStatusInactive.
 const (
-	UserStatusInactive userStatus = iota
-	UserStatusActive
-	UserStatusSynching
+	UserStatusActive   UserStatusCode = "24"
+	UserStatusInactive UserStatusCode = "25"
+	// Unverified, see the README.
+	UserStatusBlocked  UserStatusCode = "26"
+	UserStatusSynching UserStatusCode = "46"
 )
 
+// ErrNoClassifierID is returned when updating a
+// classifier field without its field ID.
+var ErrNoClassifierID = errors.New("liguetaxi: classifier field ID required")
+
+// ErrUnknownStatus is wrapped by the error returned by the
+// StrictStatus middleware for unknown user status codes.
+var ErrUnknownStatus = errors.New("liguetaxi: unknown user status")
+
+// Descriptions of the user statuses.
+var userStatusDescriptions = map[UserStatusCode]string{
+	UserStatusActive:   "Active",
+	UserStatusInactive: "Inactive",
+	UserStatusBlocked:  "Blocked",
+	UserStatusSynching: "Synching",
+}
+
 var (
 	// Endpoint for reading user info.
 	readUserEndpoint endpoint = `user/check_authorized`
@@ -31,79 +55,246 @@ var (
 
 	// Endpoint for creating classifier field.
 	createClassifierEndpoint endpoint = `user/create_authorized_field`
+
+	// Unverified endpoints, see the README.
+
+	// Endpoint for editing classifier field.
+	updateClassifierEndpoint endpoint = `user/edit_authorized_field`
+
+	// Endpoint for deleting user.
+	deleteUserEndpoint endpoint = `user/delete_authorized`
+
+	// Endpoint for deleting classifier field.
+	deleteClassifierEndpoint endpoint = `user/delete_authorized_field`
+
+	// Endpoint for listing users.
+	listUsersEndpoint endpoint = `user/list_authorized`
 )
 
-// userStatus is the user status.
-// Active - 1
-// Inactive - 0
-type userStatus int
+// DefaultUserPageSize is the number of users requested
+// per page when none is specified.
+const DefaultUserPageSize = 100
 
-// UnmarshalText implements the TextUnmarshaler interface for
-// userStatus type
-func (us *userStatus) UnmarshalJSON(t []byte) error {
-	switch string(t) {
-	case `"24"`:
-		*us = UserStatusActive
-	case `"46"`:
-		*us = UserStatusSynching
-	default:
-		*us = UserStatusInactive
+// UserStatusCode is the user status, holding the code used
+// by the API, e.g. "24" for active users. Codes unknown
+// to this package are kept as returned by the API.
+type UserStatusCode string
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// UserStatusCode type
+func (us *UserStatusCode) UnmarshalJSON(t []byte) error {
+	// The code is sent as a string, but json.Number
+	// also accepts it as a number.
+	var code json.Number
+	if err := json.Unmarshal(t, &code); err != nil {
+		var s string
+		if err := json.Unmarshal(t, &s); err != nil {
+			// Kept as sent, e.g. {}, so it is told
+			// apart from the known codes.
+			*us = UserStatusCode(t)
+			return nil
+		}
+		code = json.Number(s)
+	}
+
+	if code != "" {
+		*us = UserStatusCode(code)
 	}
 	return nil
 }
 
 // MarshalJSON implements the Marshaler interface for
-// userStatus type
-func (us *userStatus) MarshalJSON() ([]byte, error) {
+// UserStatusCode type
+func (us *UserStatusCode) MarshalJSON() ([]byte, error) {
 	if us == nil {
 		return []byte(`null`), nil
 	}
 
-	switch *us {
-	case UserStatusActive:
-		return []byte(`"24"`), nil
-	case UserStatusSynching:
-		return []byte(`"46"`), nil
-	default:
-		return []byte(`"25"`), nil
+	code := *us
+	if code == "" {
+		code = UserStatusInactive
 	}
-	return nil, nil
+	return json.Marshal(string(code))
 }
 
-// New return a pointer to userStatus.
-func (us userStatus) New() *userStatus {
-	return &us
+// UnmarshalText implements the TextUnmarshaler interface for
+// UserStatusCode type
+func (us *UserStatusCode) UnmarshalText(t []byte) error {
+	*us = UserStatusCode(t)
+	return nil
+}
+
+// MarshalText implements the TextMarshaler interface for
+// UserStatusCode type
+func (us UserStatusCode) MarshalText() ([]byte, error) {
+	if us == "" {
+		us = UserStatusInactive
+	}
+	return []byte(us), nil
 }
 
-// emptObjToStr is the field that should return an string
-// but returns an empty object when string is empty.
-type emptyObjToStr string
+// String returns the description of the status,
+// or its code if unknown to this package.
+func (us UserStatusCode) String() string {
+	if !us.Known() {
+		return string(us)
+	}
+	return us.Description()
+}
 
-// UnmarshalJSON implements the Unmarshaler interface for
-// emptyObjToStr type
-func (e *emptyObjToStr) UnmarshalJSON(b []byte) error {
-	var s string
-	if token := string(b); token != `{}` {
-		if err := json.Unmarshal(b, &s); err != nil {
+// Raw returns the code of the status as sent by the API.
+func (us UserStatusCode) Raw() string {
+	return string(us)
+}
+
+// Known reports whether the status code is known to this package.
+// The empty code is known, as UserStatusInactive.
+func (us UserStatusCode) Known() bool {
+	if us == "" {
+		us = UserStatusInactive
+	}
+
+	_, ok := userStatusDescriptions[us]
+	return ok
+}
+
+// Description returns the description of the status,
+// or "Unknown" for codes unknown to this package.
+func (us UserStatusCode) Description() string {
+	if us == "" {
+		us = UserStatusInactive
+	}
+
+	if d, ok := userStatusDescriptions[us]; ok {
+		return d
+	}
+	return "Unknown"
+}
+
+// StrictStatus is a Middleware that fails the user reads whose
+// status code is unknown to this package, instead of returning it.
+func StrictStatus(next Handler) Handler {
+	return func(ctx context.Context, method, endpoint string, body, output interface{}) error {
+		if err := next(ctx, method, endpoint, body, output); err != nil {
 			return err
 		}
-		*e = emptyObjToStr(s)
+
+		if u, ok := output.(*UserResponse); ok {
+			if s := u.Data.Status; s != nil && !s.Known() {
+				return fmt.Errorf("%w: %q", ErrUnknownStatus, s.Raw())
+			}
+		}
+		return nil
 	}
-	return nil
 }
 
-// String returns underlying string for
-// emptyObjToStr type.
-func (e emptyObjToStr) String() string {
-	return string(e)
+// New return a pointer to UserStatusCode.
+func (us UserStatusCode) New() *UserStatusCode {
+	return &us
 }
 
+// Keys holding the identifier of the created entity
+// in the operation responses, by order of precedence.
+var createdIDKeys = []string{"id", "ride_id", "authorized_id", "field_id", "unique_field"}
+
+// maxIDLen is the maximum length of the identifiers
+// taken from single values, see isNumericID.
+const maxIDLen = 64
+
 // OperationResponse is the response returned by the API
 // for non-idempotent operations on user.
 type OperationResponse struct {
-	Status reqStatus
+	Status ReqStatus
+
+	Message FlexString `json:"message"`
+
+	// CreatedID is the identifier of the created or
+	// edited entity, when returned by the API.
+	CreatedID string `json:"-"`
+
+	// Fields are the fields returned by the API in the
+	// response data, including the identifier.
+	Fields map[string]string `json:"-"`
+
+	// Raw is the response as returned by the API.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// OperationResponse type. The data returned is decoded into
+// CreatedID and Fields, either from a single value, taken as the
+// identifier if numeric, or from an object.
+func (op *OperationResponse) UnmarshalJSON(b []byte) error {
+	var r struct {
+		Status  ReqStatus
+		Message FlexString      `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return err
+	}
+
+	*op = OperationResponse{
+		Status:  r.Status,
+		Message: r.Message,
+		Raw:     append(json.RawMessage(nil), b...),
+	}
 
-	Message string `json:"message"`
+	if isEmptyJSON(r.Data) {
+		// The identifier may come along the status.
+		var top map[string]json.RawMessage
+		json.Unmarshal(b, &top)
+		op.CreatedID = createdID(top)
+		return nil
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(r.Data, &data); err != nil {
+		// Other data, such as lists, is only kept in Raw. Text, such
+		// as "Sucesso" or "Campo 10 criado", is not an identifier.
+		var id FlexString
+		if json.Unmarshal(r.Data, &id) == nil && isNumericID(id.String()) {
+			op.CreatedID = id.String()
+		}
+		return nil
+	}
+
+	op.CreatedID = createdID(data)
+	op.Fields = make(map[string]string, len(data))
+	for k, v := range data {
+		var f FlexString
+		if err := json.Unmarshal(v, &f); err != nil {
+			// Nested values are kept as sent.
+			f = FlexString(v)
+		}
+		op.Fields[k] = f.String()
+	}
+	return nil
+}
+
+// isNumericID reports whether s is a numeric identifier.
+func isNumericID(s string) bool {
+	if s == "" || len(s) > maxIDLen {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// createdID returns the value of the first identifier key found.
+func createdID(m map[string]json.RawMessage) string {
+	for _, k := range createdIDKeys {
+		var id FlexString
+		if v, ok := m[k]; ok && json.Unmarshal(v, &id) == nil && id != "" {
+			return id.String()
+		}
+	}
+	return ""
 }
 
 // ClassifierOperationResponse is the response returned by the API
@@ -111,23 +302,76 @@ type OperationResponse struct {
 type ClassifierOperationResponse struct {
 	OperationResponse
 
-	Data string `json:"data"`
+	// Data is the data returned by the API, if a single value.
+	Data FlexString `json:"data"`
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// ClassifierOperationResponse type.
+func (co *ClassifierOperationResponse) UnmarshalJSON(b []byte) error {
+	if err := co.OperationResponse.UnmarshalJSON(b); err != nil {
+		return err
+	}
+
+	var r struct {
+		Data json.RawMessage `json:"data"`
+	}
+	json.Unmarshal(b, &r)
+
+	co.Data = ""
+	if co.Fields == nil && !isEmptyJSON(r.Data) {
+		return json.Unmarshal(r.Data, &co.Data)
+	}
+	return nil
 }
 
 // DataUser is the result from check user request.
 type DataUser struct {
-	ID                string         `json:"authorized_id"`
-	Name              string         `json:"client_name"`
-	Email             *emptyObjToStr `json:"client_email"`
-	Phone             *emptyObjToStr `json:"client_phone"`
-	Status            *userStatus    `json:"cod_status"`
-	StatusDescription string         `json:"status_description"`
+	ID                FlexString      `json:"authorized_id"`
+	UniqueField       FlexString      `json:"unique_field"`
+	Name              FlexString      `json:"client_name"`
+	Email             *FlexString     `json:"client_email"`
+	Phone             *FlexString     `json:"client_phone"`
+	Status            *UserStatusCode `json:"cod_status"`
+	StatusDescription FlexString      `json:"status_description"`
+}
+
+// IsActive reports whether the user is active.
+func (d DataUser) IsActive() bool {
+	return d.hasStatus(UserStatusActive)
+}
+
+// IsInactive reports whether the user is inactive.
+func (d DataUser) IsInactive() bool {
+	return d.hasStatus(UserStatusInactive)
+}
+
+// IsBlocked reports whether the user is blocked.
+func (d DataUser) IsBlocked() bool {
+	return d.hasStatus(UserStatusBlocked)
+}
+
+// IsSynching reports whether the user status is being synched.
+func (d DataUser) IsSynching() bool {
+	return d.hasStatus(UserStatusSynching)
+}
+
+func (d DataUser) hasStatus(s UserStatusCode) bool {
+	if d.Status == nil {
+		return false
+	}
+
+	us := *d.Status
+	if us == "" {
+		us = UserStatusInactive
+	}
+	return us == s
 }
 
 // UserResponse is the response returned by the API
 // when listing a user info.
 type UserResponse struct {
-	Status reqStatus
+	Status ReqStatus
 
 	Data DataUser `json:"data"`
 }
@@ -167,12 +411,46 @@ type User struct {
 	Classifier20 string `json:"classificador20,omitempty"`
 }
 
+// SetClassifier sets the value of the classifier field, from "1" for
+// Classifier1 to "20" for Classifier20. It returns an error for
+// unknown fields.
+func (u *User) SetClassifier(field, value string) error {
+	f := reflect.ValueOf(u).Elem().FieldByName("Classifier" + field)
+	if n, err := strconv.Atoi(field); err != nil || n < 1 || !f.IsValid() {
+		return fmt.Errorf("liguetaxi: unknown classifier field %q", field)
+	}
+
+	f.SetString(value)
+	return nil
+}
+
+// Classifier returns the value of the classifier field, from "1"
+// for Classifier1 to "20" for Classifier20, or empty if unknown.
+func (u *User) Classifier(field string) string {
+	if n, err := strconv.Atoi(field); err != nil || n < 1 {
+		return ""
+	}
+
+	f := reflect.ValueOf(u).Elem().FieldByName("Classifier" + field)
+	if !f.IsValid() {
+		return ""
+	}
+	return f.String()
+}
+
 // UserStatus is the user status infos.
 type UserStatus struct {
-	ID     string     `json:"authorized_id"`
-	Name   string     `json:"user_name,omitempty"`
-	Status userStatus `json:"status"`
-	Reason string     `json:"reason,omitempty"`
+	ID     string         `json:"authorized_id"`
+	Name   string         `json:"user_name,omitempty"`
+	Status UserStatusCode `json:"status"`
+	// Reason is sent as free text, no reason codes
+	// are known to be accepted by the API.
+	Reason string `json:"reason,omitempty"`
+}
+
+// userDelete is sent to server when deleting user.
+type userDelete struct {
+	ID string `json:"authorized_id"`
 }
 
 type classifierFilter struct {
@@ -188,14 +466,52 @@ type Classifier struct {
 	AdditionalValue string `json:"field_additional_value,omitempty"`
 }
 
+// UnmarshalJSON implements the Unmarshaler interface for
+// Classifier type, tolerating the quirks of the API.
+func (c *Classifier) UnmarshalJSON(b []byte) error {
+	var f struct {
+		ID              FlexString `json:"field_id"`
+		Field           FlexString `json:"field"`
+		Value           FlexString `json:"field_value"`
+		AdditionalValue FlexString `json:"field_additional_value"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+
+	*c = Classifier{
+		ID:              f.ID.String(),
+		Field:           f.Field.String(),
+		Value:           f.Value.String(),
+		AdditionalValue: f.AdditionalValue.String(),
+	}
+	return nil
+}
+
 // ClassifierResponse is the response returned by the API
 // when reading the classifier field info.
 type ClassifierResponse struct {
-	Status reqStatus
+	Status ReqStatus
 
 	Data []Classifier `json:"data"`
 }
 
+// UnmarshalJSON implements the Unmarshaler interface for
+// ClassifierResponse type. A single classifier field is
+// decoded as a list of one.
+func (cr *ClassifierResponse) UnmarshalJSON(b []byte) error {
+	var (
+		r    listResponse
+		data []Classifier
+	)
+	if err := r.decode(b, &data); err != nil {
+		return err
+	}
+
+	*cr = ClassifierResponse{Status: r.Status, Data: data}
+	return nil
+}
+
 // UserService handles the requests related to the user.
 type UserService service
 
@@ -236,11 +552,32 @@ func (us *UserService) Update(ctx context.Context, u *User) (*OperationResponse,
 func (us *UserService) UpdateStatus(ctx context.Context, s *UserStatus) (*OperationResponse, error) {
 	op := &OperationResponse{}
 
-	us.client.Request(ctx, http.MethodPost, updateUserStatusEndpoint, s, op)
+	if err := us.client.Request(ctx, http.MethodPost, updateUserStatusEndpoint, s, op); err != nil {
+		return op, err
+	}
 
 	return op, nil
 }
 
+// Activate returns the status operation for activating the user
+// with the authorized ID or an error.
+func (us *UserService) Activate(ctx context.Context, id string) (*OperationResponse, error) {
+	return us.UpdateStatus(ctx, &UserStatus{ID: id, Status: UserStatusActive})
+}
+
+// Deactivate returns the status operation for deactivating the user
+// with the authorized ID for the reason or an error.
+func (us *UserService) Deactivate(ctx context.Context, id, reason string) (*OperationResponse, error) {
+	return us.UpdateStatus(ctx, &UserStatus{ID: id, Status: UserStatusInactive, Reason: reason})
+}
+
+// Block returns the status operation for blocking the user
+// with the authorized ID for the reason or an error. The
+// blocked status is unverified, see the README.
+func (us *UserService) Block(ctx context.Context, id, reason string) (*OperationResponse, error) {
+	return us.UpdateStatus(ctx, &UserStatus{ID: id, Status: UserStatusBlocked, Reason: reason})
+}
+
 // ReadClassifier returns the classifier field info.
 func (us *UserService) ReadClassifier(ctx context.Context, field, value string) (*ClassifierResponse, error) {
 	c := &ClassifierResponse{}
@@ -262,3 +599,176 @@ func (us *UserService) CreateClassifier(ctx context.Context, c *Classifier) (*Cl
 
 	return co, nil
 }
+
+// UpdateClassifier returns the status operation for updating the classifier
+// field with the field ID, e.g. to rename its value, or an error. Classifier
+// fields without ID fail with ErrNoClassifierID.
+func (us *UserService) UpdateClassifier(ctx context.Context, c *Classifier) (*ClassifierOperationResponse, error) {
+	co := &ClassifierOperationResponse{}
+
+	if c != nil && c.ID == "" {
+		return co, ErrNoClassifierID
+	}
+
+	if err := us.client.Request(ctx, http.MethodPost, updateClassifierEndpoint, c, co); err != nil {
+		return co, err
+	}
+
+	return co, nil
+}
+
+// Delete returns the status operation for deleting the user
+// with the authorized ID or an error.
+func (us *UserService) Delete(ctx context.Context, id string) (*OperationResponse, error) {
+	op := &OperationResponse{}
+
+	if err := us.client.Request(ctx, http.MethodPost, deleteUserEndpoint, userDelete{id}, op); err != nil {
+		return op, err
+	}
+
+	return op, nil
+}
+
+// DeleteClassifier returns the status operation for deleting
+// the value of the classifier field or an error.
+func (us *UserService) DeleteClassifier(ctx context.Context, field, value string) (*ClassifierOperationResponse, error) {
+	co := &ClassifierOperationResponse{}
+
+	if err := us.client.Request(ctx, http.MethodPost, deleteClassifierEndpoint, classifierFilter{field, value}, co); err != nil {
+		return co, err
+	}
+
+	return co, nil
+}
+
+// UserFilter filters the users listed. Zero values are not filtered.
+type UserFilter struct {
+	Status UserStatusCode
+
+	// ClassifierField and ClassifierValue filter the users by
+	// their classifier value, e.g. "1" for classificador1.
+	ClassifierField string
+	ClassifierValue string
+
+	NamePrefix   string
+	CreatedAfter time.Time
+
+	// PageSize is the number of users requested per page.
+	// If zero, DefaultUserPageSize is used.
+	PageSize int
+}
+
+// userListQuery is sent to server when listing users.
+type userListQuery struct {
+	Status          UserStatusCode `json:"status,omitempty"`
+	ClassifierField string         `json:"field,omitempty"`
+	ClassifierValue string         `json:"field_value,omitempty"`
+	NamePrefix      string         `json:"name_prefix,omitempty"`
+	NameContains    string         `json:"name_contains,omitempty"`
+	CreatedAfter    string         `json:"created_after,omitempty"`
+	Page            int            `json:"page"`
+	PageSize        int            `json:"page_size"`
+}
+
+func (f *UserFilter) query(page int) userListQuery {
+	q := userListQuery{Page: page, PageSize: DefaultUserPageSize}
+	if f == nil {
+		return q
+	}
+
+	if !f.CreatedAfter.IsZero() {
+		q.CreatedAfter = f.CreatedAfter.Format(reportDateLayout)
+	}
+	if f.PageSize > 0 {
+		q.PageSize = f.PageSize
+	}
+
+	q.Status, q.NamePrefix = f.Status, f.NamePrefix
+	q.ClassifierField, q.ClassifierValue = f.ClassifierField, f.ClassifierValue
+	return q
+}
+
+// UserListResponse is the response returned by the API
+// when listing a page of users.
+type UserListResponse struct {
+	Status  ReqStatus
+	Message FlexString
+
+	Data []DataUser
+	// Page is the number of the page, starting at 1.
+	Page int
+	// TotalPages is the number of pages, zero if unknown.
+	TotalPages int
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// UserListResponse type.
+func (ul *UserListResponse) UnmarshalJSON(b []byte) error {
+	var (
+		r    listResponse
+		data []DataUser
+	)
+	if err := r.decode(b, &data); err != nil {
+		return err
+	}
+
+	*ul = UserListResponse{
+		Status:     r.Status,
+		Message:    r.Message,
+		Data:       data,
+		Page:       int(r.Page),
+		TotalPages: int(r.TotalPages),
+	}
+	return nil
+}
+
+func (ul *UserListResponse) page() (ReqStatus, FlexString, interface{}, int) {
+	return ul.Status, ul.Message, ul.Data, ul.TotalPages
+}
+
+// ListPage returns the page of users, starting at 1, or an error.
+func (us *UserService) ListPage(ctx context.Context, f *UserFilter, page int) (*UserListResponse, error) {
+	return us.listPage(ctx, f.query(page))
+}
+
+func (us *UserService) listPage(ctx context.Context, q userListQuery) (*UserListResponse, error) {
+	ul := &UserListResponse{}
+
+	if err := us.client.Request(ctx, http.MethodPost, listUsersEndpoint, q, ul); err != nil {
+		return ul, err
+	}
+
+	return ul, nil
+}
+
+// List returns an iterator over the users,
+// requesting the pages as needed.
+func (us *UserService) List(f *UserFilter) *UserIterator {
+	return us.iterate(f, "")
+}
+
+// Search returns an iterator over the users whose name contains
+// the text, also filtered by f, requesting the pages as needed.
+func (us *UserService) Search(name string, f *UserFilter) *UserIterator {
+	return us.iterate(f, name)
+}
+
+func (us *UserService) iterate(f *UserFilter, name string) *UserIterator {
+	return &UserIterator{NewIterator(listPages("users", func(ctx context.Context, page int) (pageResponse, error) {
+		q := f.query(page)
+		q.NameContains = name
+
+		return us.listPage(ctx, q)
+	}))}
+}
+
+// UserIterator iterates over the users listed.
+type UserIterator struct {
+	*Iterator
+}
+
+// User returns the current user.
+func (it *UserIterator) User() DataUser {
+	u, _ := it.Value().(DataUser)
+	return u
+}
@@ -3,75 +3,389 @@ package liguetaxi
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestUserStatusUnmarshalJSON(t *testing.T) {
 	testCases := []struct {
 		b    []byte
-		want userStatus
+		want UserStatusCode
 	}{
 		{[]byte(`"24"`), UserStatusActive},
 		{[]byte(`"25"`), UserStatusInactive},
 		{[]byte(`"46"`), UserStatusSynching},
-		{[]byte(`"46"`), UserStatusSynching},
+		{[]byte(`"26"`), UserStatusBlocked},
+		{[]byte(`"99"`), UserStatusCode("99")},
+		{[]byte(`99`), UserStatusCode("99")},
+		{[]byte(`"A"`), UserStatusCode("A")},
+		{[]byte(`{}`), UserStatusCode("{}")},
+		{[]byte(`true`), UserStatusCode("true")},
 	}
 
 	for _, tc := range testCases {
-		var status userStatus
+		var status UserStatusCode
 
 		if err := status.UnmarshalJSON(tc.b); err != nil {
-			t.Fatalf("got error calling userStatus.UnmarshalJSON(%+v): %s; want nil.", tc.b, err.Error())
+			t.Fatalf("got error calling UserStatusCode.UnmarshalJSON(%+v): %s; want nil.", tc.b, err.Error())
 		}
 
 		if status != tc.want {
-			t.Errorf("got userStatus.UnmarshalJSON(%s): %v; want %v.", tc.b, status, tc.want)
+			t.Errorf("got UserStatusCode.UnmarshalJSON(%s): %v; want %v.", tc.b, status, tc.want)
 		}
 	}
 }
 
 func TestUserStatusMarshalJSON(t *testing.T) {
 	testCases := []struct {
-		status *userStatus
+		status *UserStatusCode
 		want   []byte
 	}{
 		{UserStatusActive.New(), []byte(`"24"`)},
 		{UserStatusInactive.New(), []byte(`"25"`)},
 		{UserStatusSynching.New(), []byte(`"46"`)},
+		{UserStatusBlocked.New(), []byte(`"26"`)},
+		{UserStatusCode("99").New(), []byte(`"99"`)},
+		{UserStatusCode("").New(), []byte(`"25"`)},
 		{nil, []byte(`null`)},
 	}
 
 	for _, tc := range testCases {
 		got, err := tc.status.MarshalJSON()
 		if err != nil {
-			t.Fatalf("got error calling userStatus.MarshalJSON(): %s; want nil.", err.Error())
+			t.Fatalf("got error calling UserStatusCode.MarshalJSON(): %s; want nil.", err.Error())
 		}
 
 		if !bytes.Equal(got, tc.want) {
-			t.Errorf("got userStatus.MarshalJSON(): %s; want %s.", got, tc.want)
+			t.Errorf("got UserStatusCode.MarshalJSON(): %s; want %s.", got, tc.want)
 		}
 	}
 }
 
-func TestEmptyObjToStrUnmarshalJSON(t *testing.T) {
+func TestUserStatusDescription(t *testing.T) {
 	testCases := []struct {
-		b    []byte
-		want string
+		status UserStatusCode
+		want   string
+	}{
+		{UserStatusActive, "Active"},
+		{UserStatusInactive, "Inactive"},
+		{UserStatusBlocked, "Blocked"},
+		{UserStatusSynching, "Synching"},
+		{UserStatusCode(""), "Inactive"},
+		{UserStatusCode("99"), "Unknown"},
+	}
+
+	for _, tc := range testCases {
+		if d := tc.status.Description(); d != tc.want {
+			t.Errorf("got UserStatusCode(%s).Description(): %s; want %s.", string(tc.status), d, tc.want)
+		}
+	}
+}
+
+func TestUserStatusRaw(t *testing.T) {
+	testCases := []struct {
+		b         []byte
+		wantRaw   string
+		wantKnown bool
+	}{
+		{[]byte(`"24"`), "24", true},
+		{[]byte(`"26"`), "26", true},
+		{[]byte(`"99"`), "99", false},
+	}
+
+	for _, tc := range testCases {
+		var status UserStatusCode
+		status.UnmarshalJSON(tc.b)
+
+		if r := status.Raw(); r != tc.wantRaw {
+			t.Errorf("got UserStatusCode.Raw(): %s; want %s.", r, tc.wantRaw)
+		}
+
+		if k := status.Known(); k != tc.wantKnown {
+			t.Errorf("got UserStatusCode(%s).Known(): %t; want %t.", tc.wantRaw, k, tc.wantKnown)
+		}
+
+		// Marshaling round-trips the code.
+		if b, _ := status.MarshalJSON(); !bytes.Equal(b, tc.b) {
+			t.Errorf("got UserStatusCode.MarshalJSON(): %s; want %s.", b, tc.b)
+		}
+	}
+
+	// The empty code is known, as it is described as inactive.
+	if !UserStatusCode("").Known() {
+		t.Error("got UserStatusCode(\"\").Known(): false; want true.")
+	}
+}
+
+func TestStrictStatus(t *testing.T) {
+	testCases := []struct {
+		status  *UserStatusCode
+		err     error
+		wantErr error
+	}{
+		{UserStatusActive.New(), nil, nil},
+		{nil, nil, nil},
+		{UserStatusCode("").New(), nil, nil},
+		{UserStatusCode("99").New(), nil, ErrUnknownStatus},
+		{UserStatusCode("{}").New(), nil, ErrUnknownStatus},
+		{nil, errors.New("Error"), nil},
+	}
+
+	for _, tc := range testCases {
+		h := StrictStatus(func(ctx context.Context, method, endpoint string, body, output interface{}) error {
+			output.(*UserResponse).Data.Status = tc.status
+			return tc.err
+		})
+
+		err := h(context.Background(), http.MethodPost, string(readUserEndpoint), nil, &UserResponse{})
+
+		switch {
+		case tc.err != nil:
+			if err != tc.err {
+				t.Errorf("got error: %v; want %v.", err, tc.err)
+			}
+		case tc.wantErr != nil:
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("got error: %v; want it to wrap %v.", err, tc.wantErr)
+			}
+		case err != nil:
+			t.Errorf("got error: %v; want nil.", err)
+		}
+	}
+}
+
+func TestUserStatusCodeText(t *testing.T) {
+	testCases := []struct {
+		status   UserStatusCode
+		wantText string
+		wantStr  string
+	}{
+		{UserStatusActive, "24", "Active"},
+		{UserStatusBlocked, "26", "Blocked"},
+		{UserStatusCode(""), "25", "Inactive"},
+		{UserStatusCode("99"), "99", "99"},
+	}
+
+	for _, tc := range testCases {
+		text, err := tc.status.MarshalText()
+		if err != nil {
+			t.Fatalf("got error calling UserStatusCode.MarshalText(): %s; want nil.", err.Error())
+		}
+
+		if string(text) != tc.wantText {
+			t.Errorf("got UserStatusCode.MarshalText(): %s; want %s.", text, tc.wantText)
+		}
+
+		var back UserStatusCode
+		back.UnmarshalText(text)
+		if back.Raw() != tc.wantText {
+			t.Errorf("got UserStatusCode %s from text %s; want %s.", back.Raw(), text, tc.wantText)
+		}
+
+		if s := tc.status.String(); s != tc.wantStr {
+			t.Errorf("got UserStatusCode.String(): %s; want %s.", s, tc.wantStr)
+		}
+	}
+
+	// The status is marshaled by value as well.
+	b, _ := json.Marshal(struct{ S UserStatusCode }{UserStatusActive})
+	if want := `{"S":"24"}`; string(b) != want {
+		t.Errorf("got %s; want %s.", b, want)
+	}
+}
+
+func TestDataUserStatus(t *testing.T) {
+	testCases := []struct {
+		user DataUser
+		want [4]bool
+	}{
+		{DataUser{Status: UserStatusActive.New()}, [4]bool{true, false, false, false}},
+		{DataUser{Status: UserStatusInactive.New()}, [4]bool{false, true, false, false}},
+		{DataUser{Status: UserStatusBlocked.New()}, [4]bool{false, false, true, false}},
+		{DataUser{Status: UserStatusSynching.New()}, [4]bool{false, false, false, true}},
+		{DataUser{Status: UserStatusCode("").New()}, [4]bool{false, true, false, false}},
+		{DataUser{}, [4]bool{}},
+	}
+
+	for _, tc := range testCases {
+		got := [4]bool{tc.user.IsActive(), tc.user.IsInactive(), tc.user.IsBlocked(), tc.user.IsSynching()}
+		if got != tc.want {
+			t.Errorf("got DataUser{Status: %v} Is{Active,Inactive,Blocked,Synching}(): %v; want %v.", tc.user.Status, got, tc.want)
+		}
+	}
+}
+
+func TestUserResponseUnmarshalJSON(t *testing.T) {
+	b := []byte(`{
+		"status": "1",
+		"data": {
+			"authorized_id": 1234,
+			"client_name": "Test",
+			"client_email": {},
+			"client_phone": 11986548744,
+			"cod_status": 24,
+			"status_description": []
+		}
+	}`)
+
+	var got UserResponse
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("got error unmarshaling UserResponse: %s; want nil.", err.Error())
+	}
+
+	email, phone := FlexString(""), FlexString("11986548744")
+	want := UserResponse{
+		Status: ReqStatusOK,
+		Data: DataUser{
+			ID:     "1234",
+			Name:   "Test",
+			Email:  &email,
+			Phone:  &phone,
+			Status: UserStatusActive.New(),
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got UserResponse: %+v; want %+v.", got, want)
+	}
+}
+
+func TestClassifierResponseUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b    string
+		want ClassifierResponse
 	}{
-		{[]byte(`"non-empty string"`), "non-empty string"},
-		{[]byte(`{}`), ""},
+		{
+			`{"status":1,"data":[{"field_id":10,"field":"1","field_value":"test","field_additional_value":{}}]}`,
+			ClassifierResponse{ReqStatusOK, []Classifier{{ID: "10", Field: "1", Value: "test"}}},
+		},
+		{
+			`{"status":1,"data":{"field_id":"10","field":1,"field_value":"test"}}`,
+			ClassifierResponse{ReqStatusOK, []Classifier{{ID: "10", Field: "1", Value: "test"}}},
+		},
+		{
+			`{"status":0,"data":{}}`,
+			ClassifierResponse{ReqStatusFail, nil},
+		},
+		{
+			`{"status":0,"data":""}`,
+			ClassifierResponse{ReqStatusFail, nil},
+		},
 	}
 
 	for _, tc := range testCases {
-		var str emptyObjToStr
+		var got ClassifierResponse
+		if err := json.Unmarshal([]byte(tc.b), &got); err != nil {
+			t.Fatalf("got error unmarshaling ClassifierResponse %s: %s; want nil.", tc.b, err.Error())
+		}
 
-		str.UnmarshalJSON(tc.b)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("got ClassifierResponse from %s: %+v; want %+v.", tc.b, got, tc.want)
+		}
+	}
+}
 
-		if string(str) != tc.want {
-			t.Errorf("got emptyObjToStr.UnmarshalJSON(%s): %v; want %s.", tc.b, str, tc.want)
+func TestOperationResponseUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b    string
+		want OperationResponse
+	}{
+		{
+			`{"status":1,"message":"Created","data":{"authorized_id":123,"client_name":"Test","extra":{"a":1}}}`,
+			OperationResponse{
+				Status:    ReqStatusOK,
+				Message:   "Created",
+				CreatedID: "123",
+				Fields: map[string]string{
+					"authorized_id": "123",
+					"client_name":   "Test",
+					"extra":         `{"a":1}`,
+				},
+			},
+		},
+		{
+			`{"status":"1","message":"Created","data":"456"}`,
+			OperationResponse{Status: ReqStatusOK, Message: "Created", CreatedID: "456"},
+		},
+		{
+			`{"status":1,"message":"Created","field_id":"789","data":{}}`,
+			OperationResponse{Status: ReqStatusOK, Message: "Created", CreatedID: "789"},
+		},
+		{
+			`{"status":0,"message":{},"data":[1,2]}`,
+			OperationResponse{Status: ReqStatusFail},
+		},
+		{
+			`{"status":1,"message":"OK","data":"Campo 10 criado"}`,
+			OperationResponse{Status: ReqStatusOK, Message: "OK"},
+		},
+		{
+			`{"status":1,"message":"OK","data":"Sucesso"}`,
+			OperationResponse{Status: ReqStatusOK, Message: "OK"},
+		},
+		{
+			`{"status":1,"message":"OK","data":"OK"}`,
+			OperationResponse{Status: ReqStatusOK, Message: "OK"},
+		},
+		{
+			`{"status":1,"message":"OK","data":"a1b2-c3d4"}`,
+			OperationResponse{Status: ReqStatusOK, Message: "OK"},
+		},
+		{
+			`{"status":1,"message":"OK","data":{"id":"a1b2-c3d4"}}`,
+			OperationResponse{Status: ReqStatusOK, Message: "OK", CreatedID: "a1b2-c3d4", Fields: map[string]string{"id": "a1b2-c3d4"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		var got OperationResponse
+		if err := json.Unmarshal([]byte(tc.b), &got); err != nil {
+			t.Fatalf("got error unmarshaling OperationResponse %s: %s; want nil.", tc.b, err.Error())
+		}
+
+		if string(got.Raw) != tc.b {
+			t.Errorf("got OperationResponse.Raw: %s; want %s.", got.Raw, tc.b)
+		}
+
+		got.Raw = nil
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("got OperationResponse from %s: %+v; want %+v.", tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestClassifierOperationResponseUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		b          string
+		wantData   FlexString
+		wantID     string
+		wantFields map[string]string
+	}{
+		{`{"status":1,"message":"OK","data":"10"}`, "10", "10", nil},
+		{`{"status":1,"message":"OK","data":{"field_id":"10"}}`, "", "10", map[string]string{"field_id": "10"}},
+		{`{"status":1,"message":"OK","data":"Campo 10 criado"}`, "Campo 10 criado", "", nil},
+		{`{"status":1,"message":"OK","data":"Sucesso"}`, "Sucesso", "", nil},
+		{`{"status":0,"message":"Error","data":{}}`, "", "", nil},
+	}
+
+	for _, tc := range testCases {
+		var got ClassifierOperationResponse
+		if err := json.Unmarshal([]byte(tc.b), &got); err != nil {
+			t.Fatalf("got error unmarshaling ClassifierOperationResponse %s: %s; want nil.", tc.b, err.Error())
+		}
+
+		if got.Data != tc.wantData || got.CreatedID != tc.wantID || !reflect.DeepEqual(got.Fields, tc.wantFields) {
+			t.Errorf("got Data %q, CreatedID %q and Fields %v from %s; want %q, %q and %v.",
+				got.Data, got.CreatedID, got.Fields, tc.b, tc.wantData, tc.wantID, tc.wantFields)
+		}
+
+		if got.Status == ReqStatusOK && got.Message != "OK" {
+			t.Errorf("got Message %q; want OK.", got.Message)
 		}
 	}
 }
@@ -170,6 +484,48 @@ func TestUser(t *testing.T) {
 				Status: ReqStatusOK,
 			},
 		},
+		{
+			"Activate()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&UserService{req}).Activate(ctx, "1")
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			updateUserStatusEndpoint,
+			&UserStatus{ID: "1", Status: UserStatusActive},
+			&OperationResponse{
+				Status: ReqStatusOK,
+			},
+		},
+		{
+			"Deactivate()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&UserService{req}).Deactivate(ctx, "1", "Fired")
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			updateUserStatusEndpoint,
+			&UserStatus{ID: "1", Status: UserStatusInactive, Reason: "Fired"},
+			&OperationResponse{
+				Status: ReqStatusOK,
+			},
+		},
+		{
+			"Block()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&UserService{req}).Block(ctx, "1", "Fraud")
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			updateUserStatusEndpoint,
+			&UserStatus{ID: "1", Status: UserStatusBlocked, Reason: "Fraud"},
+			&OperationResponse{
+				Status: ReqStatusOK,
+			},
+		},
 		{
 			"ReadClassifier()",
 			func(ctx context.Context, req requester) (resp interface{}, err error) {
@@ -200,6 +556,67 @@ func TestUser(t *testing.T) {
 				},
 			},
 		},
+		{
+			"UpdateClassifier()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&UserService{req}).UpdateClassifier(ctx, &Classifier{ID: "10", Value: "CC-200", AdditionalValue: "Sales"})
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			updateClassifierEndpoint,
+			&Classifier{ID: "10", Value: "CC-200", AdditionalValue: "Sales"},
+			&ClassifierOperationResponse{
+				OperationResponse: OperationResponse{
+					Status: ReqStatusOK,
+				},
+			},
+		},
+		{
+			"Delete()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&UserService{req}).Delete(ctx, "1")
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			deleteUserEndpoint,
+			userDelete{"1"},
+			&OperationResponse{
+				Status: ReqStatusOK,
+			},
+		},
+		{
+			"DeleteClassifier()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&UserService{req}).DeleteClassifier(ctx, "1", "CC-100")
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			deleteClassifierEndpoint,
+			classifierFilter{Field: "1", Value: "CC-100"},
+			&ClassifierOperationResponse{
+				OperationResponse: OperationResponse{
+					Status: ReqStatusOK,
+				},
+			},
+		},
+		{
+			"ListPage()",
+			func(ctx context.Context, req requester) (resp interface{}, err error) {
+				resp, err = (&UserService{req}).ListPage(ctx, &UserFilter{Status: UserStatusInactive}, 2)
+				return
+			},
+			context.Background(),
+			http.MethodPost,
+			listUsersEndpoint,
+			userListQuery{Status: UserStatusInactive, Page: 2, PageSize: DefaultUserPageSize},
+			&UserListResponse{
+				Status: ReqStatusOK,
+				Data:   []DataUser{{ID: "123"}},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -265,6 +682,54 @@ func TestUserError(t *testing.T) {
 			},
 			errors.New("Error"),
 		},
+		{
+			"UpdateStatus()",
+			func(req requester) error {
+				_, err := (&UserService{req}).UpdateStatus(context.Background(), nil)
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"UpdateClassifier()",
+			func(req requester) error {
+				_, err := (&UserService{req}).UpdateClassifier(context.Background(), nil)
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"UpdateClassifier() without ID",
+			func(req requester) error {
+				_, err := (&UserService{req}).UpdateClassifier(context.Background(), &Classifier{Value: "CC-200"})
+				return err
+			},
+			ErrNoClassifierID,
+		},
+		{
+			"Delete()",
+			func(req requester) error {
+				_, err := (&UserService{req}).Delete(context.Background(), "1")
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"DeleteClassifier()",
+			func(req requester) error {
+				_, err := (&UserService{req}).DeleteClassifier(context.Background(), "1", "CC-100")
+				return err
+			},
+			errors.New("Error"),
+		},
+		{
+			"ListPage()",
+			func(req requester) error {
+				_, err := (&UserService{req}).ListPage(context.Background(), nil, 1)
+				return err
+			},
+			errors.New("Error"),
+		},
 		{
 			"ReadClassifier()",
 			func(req requester) error {
@@ -295,3 +760,157 @@ func TestUserError(t *testing.T) {
 		})
 	}
 }
+
+func TestUserListResponseUnmarshalJSON(t *testing.T) {
+	b := `{"status":1,"page":"1","total_pages":2,"data":[{"authorized_id":1,"client_name":"João da Silva","cod_status":"25"}]}`
+
+	var got UserListResponse
+	if err := json.Unmarshal([]byte(b), &got); err != nil {
+		t.Fatalf("got error unmarshaling UserListResponse: %s; want nil.", err.Error())
+	}
+
+	want := UserListResponse{
+		Status:     ReqStatusOK,
+		Data:       []DataUser{{ID: "1", Name: "João da Silva", Status: UserStatusInactive.New()}},
+		Page:       1,
+		TotalPages: 2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got UserListResponse %+v; want %+v.", got, want)
+	}
+}
+
+func TestUserList(t *testing.T) {
+	filter := &UserFilter{
+		Status:          UserStatusInactive,
+		ClassifierField: "1",
+		ClassifierValue: "CC-100",
+		NamePrefix:      "João",
+		CreatedAfter:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		PageSize:        2,
+	}
+
+	testCases := []struct {
+		name      string
+		call      func(us *UserService) *UserIterator
+		wantQuery userListQuery
+	}{
+		{
+			"List()",
+			func(us *UserService) *UserIterator { return us.List(filter) },
+			userListQuery{UserStatusInactive, "1", "CC-100", "João", "", "2026-01-01", 1, 2},
+		},
+		{
+			"Search()",
+			func(us *UserService) *UserIterator { return us.Search("Silva", filter) },
+			userListQuery{UserStatusInactive, "1", "CC-100", "João", "Silva", "2026-01-01", 1, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // creates scoped test case
+		t.Run(tc.name, func(t *testing.T) {
+			var queries []userListQuery
+
+			us := &UserService{requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+				q := body.(userListQuery)
+				queries = append(queries, q)
+
+				ul := output.(*UserListResponse)
+				ul.Status, ul.TotalPages = ReqStatusOK, 2
+				ul.Data = []DataUser{{ID: FlexString(fmt.Sprint(q.Page))}}
+				return nil
+			})}
+
+			it := tc.call(us)
+
+			var ids []string
+			for it.Next(context.Background()) {
+				ids = append(ids, it.User().ID.String())
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatalf("got error iterating users: %s; want nil.", err.Error())
+			}
+
+			if want := []string{"1", "2"}; !reflect.DeepEqual(ids, want) {
+				t.Errorf("got users %v; want %v.", ids, want)
+			}
+
+			if len(queries) != 2 || queries[0] != tc.wantQuery {
+				t.Errorf("got queries %+v; want 2, the first %+v.", queries, tc.wantQuery)
+			}
+		})
+	}
+}
+
+func TestUserListError(t *testing.T) {
+	us := &UserService{requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		ul := output.(*UserListResponse)
+		ul.Status, ul.Message, ul.Data = ReqStatusFail, "Invalid filter", []DataUser{{ID: "1"}}
+		return nil
+	})}
+
+	it := us.List(nil)
+	if it.Next(context.Background()) {
+		t.Error("got Next() true; want false.")
+	}
+
+	want := "liguetaxi: listing users page 1 failed: Invalid filter"
+	if it.Err() == nil || it.Err().Error() != want {
+		t.Errorf("got error: %v; want %s.", it.Err(), want)
+	}
+}
+
+func TestUserListEmpty(t *testing.T) {
+	us := &UserService{requesterFunc(func(ctx context.Context, method string, path endpoint, body, output interface{}) error {
+		// The API fails the requests of empty lists.
+		return json.Unmarshal([]byte(`{"status":0,"message":"Nenhum registro encontrado","data":[]}`), output)
+	})}
+
+	it := us.List(nil)
+	if it.Next(context.Background()) {
+		t.Errorf("got user %+v; want none.", it.User())
+	}
+
+	if err := it.Err(); err != nil {
+		t.Errorf("got error: %v; want nil.", err)
+	}
+}
+
+func TestUserSetClassifier(t *testing.T) {
+	testCases := []struct {
+		field   string
+		want    User
+		wantErr bool
+	}{
+		{"1", User{Classifier1: "CC-100"}, false},
+		{"20", User{Classifier20: "CC-100"}, false},
+		{"0", User{}, true},
+		{"21", User{}, true},
+		{"01", User{}, true},
+		{"", User{}, true},
+	}
+
+	for _, tc := range testCases {
+		var u User
+		err := u.SetClassifier(tc.field, "CC-100")
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("got error calling User.SetClassifier(%q): %v; want error %t.", tc.field, err, tc.wantErr)
+		}
+
+		if u != tc.want {
+			t.Errorf("got User %+v after User.SetClassifier(%q); want %+v.", u, tc.field, tc.want)
+		}
+
+		want := "CC-100"
+		if tc.wantErr {
+			want = ""
+		}
+
+		if got := u.Classifier(tc.field); got != want {
+			t.Errorf("got User.Classifier(%q): %q; want %q.", tc.field, got, want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package webhook receives the events notified by the Ligue Taxi portal
+// about ride status changes and user status syncs.
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mobilitee-smartmob/liguetaxi"
+)
+
+// Defaults of the Handler.
+const (
+	// DefaultDedupeSize is the number of event IDs remembered.
+	DefaultDedupeSize = 4096
+	// DefaultDedupeTTL is for how long event IDs are remembered.
+	DefaultDedupeTTL = 24 * time.Hour
+	// DefaultMaxBodySize is the maximum size of an event.
+	DefaultMaxBodySize = 1 << 20
+)
+
+// EventType is the type of the event.
+type EventType string
+
+// Event types notified by the portal. Unverified, as the event
+// payload, see the README.
+const (
+	RideAccepted       EventType = "ride.accepted"
+	RideDriverArriving EventType = "ride.driver_arriving"
+	RideFinished       EventType = "ride.finished"
+	RideCancelled      EventType = "ride.cancelled"
+	UserStatusSynced   EventType = "user.status_synced"
+)
+
+// ErrUnauthorized is returned by Verify for requests
+// without the expected Authorization header.
+var ErrUnauthorized = errors.New("webhook: unauthorized")
+
+// Event is the event notified by the portal. Depending on its
+// type, either Ride or User is set.
+type Event struct {
+	ID   string
+	Type EventType
+	Time time.Time
+
+	Ride *RideEvent
+	User *UserEvent
+
+	// Raw is the event as received.
+	Raw json.RawMessage
+}
+
+// RideEvent is the data of the ride events.
+type RideEvent struct {
+	RideID       string
+	AuthorizedID string
+	Status       liguetaxi.RideStatusCode
+	Driver       liguetaxi.Driver
+	// ETA is the estimated time until the driver arrives,
+	// zero if unknown.
+	ETA time.Duration
+	// Reason is the reason of the cancellation, if any.
+	Reason string
+}
+
+// UserEvent is the data of the user events.
+type UserEvent struct {
+	AuthorizedID string
+	Status       liguetaxi.UserStatusCode
+}
+
+// UnmarshalJSON implements the Unmarshaler interface for
+// Event type, tolerating the quirks of the portal.
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var r struct {
+		ID   liguetaxi.FlexString `json:"event_id"`
+		Type liguetaxi.FlexString `json:"event"`
+		Time liguetaxi.FlexInt    `json:"timestamp"`
+		Data struct {
+			RideID       liguetaxi.FlexString     `json:"ride_id"`
+			AuthorizedID liguetaxi.FlexString     `json:"authorized_id"`
+			Status       liguetaxi.FlexString     `json:"status"`
+			CodStatus    liguetaxi.UserStatusCode `json:"cod_status"`
+			ETA          liguetaxi.FlexFloat      `json:"eta"`
+			Reason       liguetaxi.FlexString     `json:"reason"`
+			Driver       struct {
+				Name         liguetaxi.FlexString `json:"name"`
+				Phone        liguetaxi.FlexString `json:"phone"`
+				Vehicle      liguetaxi.FlexString `json:"vehicle"`
+				LicensePlate liguetaxi.FlexString `json:"license_plate"`
+			} `json:"driver"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return err
+	}
+
+	*e = Event{
+		ID:   r.ID.String(),
+		Type: EventType(r.Type),
+		Raw:  append(json.RawMessage(nil), b...),
+	}
+	if r.Time != 0 {
+		e.Time = time.Unix(r.Time.Int(), 0)
+	}
+
+	d := r.Data
+	switch e.Type {
+	case RideAccepted, RideDriverArriving, RideFinished, RideCancelled:
+		e.Ride = &RideEvent{
+			RideID:       d.RideID.String(),
+			AuthorizedID: d.AuthorizedID.String(),
+			Status:       liguetaxi.RideStatusCode(d.Status),
+			Driver: liguetaxi.Driver{
+				Name:         d.Driver.Name.String(),
+				Phone:        d.Driver.Phone.String(),
+				Vehicle:      d.Driver.Vehicle.String(),
+				LicensePlate: d.Driver.LicensePlate.String(),
+			},
+			// The ETA is sent in seconds, possibly fractional.
+			ETA:    time.Duration(d.ETA.Float() * float64(time.Second)),
+			Reason: d.Reason.String(),
+		}
+	case UserStatusSynced:
+		e.User = &UserEvent{AuthorizedID: d.AuthorizedID.String(), Status: d.CodStatus}
+	}
+	return nil
+}
+
+// Func is the callback called for the events received. Events whose
+// callback returns an error are answered with 500 Internal Server Error,
+// so the portal retries them.
+type Func func(ctx context.Context, e Event) error
+
+// Options configures the Handler.
+type Options struct {
+	// DedupeSize is the number of event IDs remembered to
+	// ignore retries. If zero, DefaultDedupeSize is used.
+	DedupeSize int
+
+	// DedupeTTL is for how long the event IDs are remembered.
+	// If zero, DefaultDedupeTTL is used.
+	DedupeTTL time.Duration
+
+	// MaxBodySize is the maximum size of an event.
+	// If zero, DefaultMaxBodySize is used.
+	MaxBodySize int64
+}
+
+// Handler is the http.Handler receiving the events of the portal.
+// The requests are verified by their Authorization header, which must
+// carry the token the same way the liguetaxi.Transport sets it, i.e.
+// "Basic <token>". Retries of an event already handled are ignored.
+type Handler struct {
+	src     liguetaxi.TokenSource
+	ttl     time.Duration
+	maxSize int64
+
+	mu   sync.Mutex
+	seen *liguetaxi.LRUCache
+
+	cbMu      sync.RWMutex
+	callbacks map[EventType][]Func
+	any       []Func
+}
+
+// NewHandler returns a Handler verifying the requests with the token
+// given by the source, e.g. liguetaxi.StaticToken or liguetaxi.BasicAuth.
+func NewHandler(src liguetaxi.TokenSource, opts *Options) *Handler {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.DedupeSize <= 0 {
+		o.DedupeSize = DefaultDedupeSize
+	}
+	if o.DedupeTTL <= 0 {
+		o.DedupeTTL = DefaultDedupeTTL
+	}
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = DefaultMaxBodySize
+	}
+
+	return &Handler{
+		src:       src,
+		ttl:       o.DedupeTTL,
+		maxSize:   o.MaxBodySize,
+		seen:      liguetaxi.NewLRUCache(o.DedupeSize),
+		callbacks: make(map[EventType][]Func),
+	}
+}
+
+// On registers the callback for the events of the type. Callbacks are
+// called in the order they were registered, stopping at the first error.
+func (h *Handler) On(t EventType, fn Func) {
+	h.cbMu.Lock()
+	defer h.cbMu.Unlock()
+
+	h.callbacks[t] = append(h.callbacks[t], fn)
+}
+
+// OnAny registers the callback for the events of any type,
+// called after the callbacks registered for the type.
+func (h *Handler) OnAny(fn Func) {
+	h.cbMu.Lock()
+	defer h.cbMu.Unlock()
+
+	h.any = append(h.any, fn)
+}
+
+// Verify returns ErrUnauthorized if the request does not
+// carry the token in the Authorization header.
+func (h *Handler) Verify(r *http.Request) error {
+	token, err := h.src.Token()
+	if err != nil {
+		return err
+	}
+
+	want := "Basic " + token
+	if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.Verify(r); err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	var e Event
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxSize)).Decode(&e); err != nil {
+		http.Error(w, "invalid event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if e.ID == "" || e.Type == "" {
+		http.Error(w, "invalid event: missing event_id or event", http.StatusBadRequest)
+		return
+	}
+
+	if !h.claim(e.ID) {
+		// Retry of an event handled or being handled.
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.dispatch(r.Context(), e); err != nil {
+		// Allows the retry.
+		h.seen.Delete(e.ID)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// claim reports whether the event was not seen yet, marking it as seen.
+func (h *Handler) claim(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.seen.Get(id); ok {
+		return false
+	}
+	h.seen.Set(id, struct{}{}, h.ttl)
+	return true
+}
+
+func (h *Handler) dispatch(ctx context.Context, e Event) error {
+	h.cbMu.RLock()
+	fns := append(append([]Func(nil), h.callbacks[e.Type]...), h.any...)
+	h.cbMu.RUnlock()
+
+	for _, fn := range fns {
+		if err := fn(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
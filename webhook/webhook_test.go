@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mobilitee-smartmob/liguetaxi"
+)
+
+const (
+	rideEvent = `{"event_id":"evt-1","event":"ride.accepted","timestamp":"1767261600","data":{"ride_id":987,"authorized_id":"123","status":"accepted","eta":"300","driver":{"name":"José","phone":11999999999,"vehicle":"Corolla","license_plate":"ABC1D23"}}}`
+	userEvent = `{"event_id":"evt-2","event":"user.status_synced","data":{"authorized_id":"123","cod_status":"24"}}`
+)
+
+func TestEventUnmarshal(t *testing.T) {
+	testCases := []struct {
+		b        string
+		wantRide *RideEvent
+		wantUser *UserEvent
+	}{
+		{
+			rideEvent,
+			&RideEvent{
+				RideID:       "987",
+				AuthorizedID: "123",
+				Status:       "accepted",
+				Driver:       liguetaxi.Driver{Name: "José", Phone: "11999999999", Vehicle: "Corolla", LicensePlate: "ABC1D23"},
+				ETA:          5 * time.Minute,
+			},
+			nil,
+		},
+		{
+			`{"event_id":"evt-4","event":"ride.driver_arriving","data":{"ride_id":"987","eta":12.5}}`,
+			&RideEvent{RideID: "987", ETA: 12500 * time.Millisecond},
+			nil,
+		},
+		{userEvent, nil, &UserEvent{AuthorizedID: "123", Status: liguetaxi.UserStatusActive}},
+		{`{"event_id":"evt-3","event":"unknown","data":{}}`, nil, nil},
+	}
+
+	for _, tc := range testCases {
+		var e Event
+		if err := e.UnmarshalJSON([]byte(tc.b)); err != nil {
+			t.Fatalf("got error unmarshaling Event %s: %s; want nil.", tc.b, err.Error())
+		}
+
+		if !reflect.DeepEqual(e.Ride, tc.wantRide) {
+			t.Errorf("got RideEvent %+v; want %+v.", e.Ride, tc.wantRide)
+		}
+
+		if !reflect.DeepEqual(e.User, tc.wantUser) {
+			t.Errorf("got UserEvent %+v; want %+v.", e.User, tc.wantUser)
+		}
+
+		if string(e.Raw) != tc.b {
+			t.Errorf("got Event.Raw %s; want %s.", e.Raw, tc.b)
+		}
+	}
+
+	var e Event
+	e.UnmarshalJSON([]byte(rideEvent))
+	if want := time.Unix(1767261600, 0); !e.Time.Equal(want) {
+		t.Errorf("got Event.Time %s; want %s.", e.Time, want)
+	}
+}
+
+func post(h http.Handler, auth, body string) int {
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	if auth != "" {
+		r.Header.Set("Authorization", auth)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(liguetaxi.BasicAuth("portal", "secret"), nil)
+	auth := "Basic " + string(liguetaxi.BasicAuth("portal", "secret"))
+
+	var (
+		mu    sync.Mutex
+		rides []string
+		all   []EventType
+	)
+	h.On(RideAccepted, func(ctx context.Context, e Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		rides = append(rides, e.Ride.RideID)
+		return nil
+	})
+	h.OnAny(func(ctx context.Context, e Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		all = append(all, e.Type)
+		return nil
+	})
+
+	testCases := []struct {
+		name string
+		auth string
+		body string
+		want int
+	}{
+		{"Ride event", auth, rideEvent, http.StatusOK},
+		{"Retry", auth, rideEvent, http.StatusOK},
+		{"User event", auth, userEvent, http.StatusOK},
+		{"No Authorization", "", rideEvent, http.StatusUnauthorized},
+		{"Wrong token", "Basic " + string(liguetaxi.BasicAuth("portal", "wrong")), rideEvent, http.StatusUnauthorized},
+		{"Invalid JSON", auth, `{"event_id":`, http.StatusBadRequest},
+		{"Missing ID", auth, `{"event":"ride.accepted"}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		if code := post(h, tc.auth, tc.body); code != tc.want {
+			t.Errorf("got status code %d for %s; want %d.", code, tc.name, tc.want)
+		}
+	}
+
+	if want := []string{"987"}; !reflect.DeepEqual(rides, want) {
+		t.Errorf("got rides %v; want %v.", rides, want)
+	}
+
+	if want := []EventType{RideAccepted, UserStatusSynced}; !reflect.DeepEqual(all, want) {
+		t.Errorf("got events %v; want %v.", all, want)
+	}
+}
+
+func TestHandlerMethod(t *testing.T) {
+	h := NewHandler(liguetaxi.StaticToken("token"), nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhook", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status code %d; want %d.", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandlerCallbackError(t *testing.T) {
+	h := NewHandler(liguetaxi.StaticToken("token"), nil)
+
+	var calls int
+	h.On(RideAccepted, func(ctx context.Context, e Event) error {
+		calls++
+		if calls == 1 {
+			return errors.New("Error")
+		}
+		return nil
+	})
+
+	// The failed event is handled again when retried.
+	for _, want := range []int{http.StatusInternalServerError, http.StatusOK, http.StatusOK} {
+		if code := post(h, "Basic token", rideEvent); code != want {
+			t.Errorf("got status code %d; want %d.", code, want)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("got %d callback calls; want 2.", calls)
+	}
+}
+
+func TestHandlerBodySize(t *testing.T) {
+	h := NewHandler(liguetaxi.StaticToken("token"), &Options{MaxBodySize: 16})
+
+	if code := post(h, "Basic token", rideEvent); code != http.StatusBadRequest {
+		t.Errorf("got status code %d; want %d.", code, http.StatusBadRequest)
+	}
+}
+
+func TestHandlerServer(t *testing.T) {
+	h := NewHandler(liguetaxi.StaticToken("token"), nil)
+
+	done := make(chan Event, 1)
+	h.On(RideFinished, func(ctx context.Context, e Event) error {
+		done <- e
+		return nil
+	})
+
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, s.URL, strings.NewReader(`{"event_id":"evt-9","event":"ride.finished","data":{"ride_id":"1"}}`))
+	req.Header.Set("Authorization", "Basic token")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("got error posting event: %s; want nil.", err.Error())
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got status code %d; want %d.", res.StatusCode, http.StatusOK)
+	}
+
+	if e := <-done; e.ID != "evt-9" || e.Ride.RideID != "1" {
+		t.Errorf("got event %s of ride %s; want evt-9 of ride 1.", e.ID, e.Ride.RideID)
+	}
+}